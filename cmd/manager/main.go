@@ -8,6 +8,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -21,6 +22,7 @@ import (
 
 	"github.com/mittwald/kubernetes-secret-generator/pkg/apis"
 	"github.com/mittwald/kubernetes-secret-generator/pkg/controller"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/webhook"
 	"github.com/mittwald/kubernetes-secret-generator/version"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
@@ -66,9 +68,14 @@ func main() {
 	pflag.Bool("regenerate-insecure", false, "Set this to automatically regenerate secrets that were generated with an non-cryptographically secure PRNG.")
 	pflag.String("secret-length", "40", "Secret length")
 	pflag.Int("ssh-key-length", 2048, "Default length of SSH Keys")
+	pflag.String("ssh-key-algorithm", "rsa", "Default algorithm of SSH Keys (rsa, ed25519, ecdsa-p256, ecdsa-p384 or ecdsa-p521)")
+	pflag.Duration("certificate-renew-before", 30*24*time.Hour, "Default duration before expiry at which generated certificates are renewed")
 	pflag.String("secret-encoding", "base64", "Encoding for secrets")
 	pflag.Bool("use-metrics-service", false, "Whether or not to use metrics service")
 	pflag.Bool("disable-crd-support", false, "Whether to disable CRD support and registering")
+	pflag.Bool("enable-webhook", false, "Whether to serve the admission webhook generating secrets on creation")
+	pflag.Int("webhook-port", 9443, "Port the admission webhook is served at")
+	pflag.String("webhook-cert-dir", "", "Directory containing the tls.crt and tls.key of the admission webhook")
 
 	pflag.Parse()
 
@@ -138,6 +145,11 @@ func main() {
 		HealthProbeBindAddress: ":8080",
 	}
 
+	if viper.GetBool("enable-webhook") {
+		options.Port = viper.GetInt("webhook-port")
+		options.CertDir = viper.GetString("webhook-cert-dir")
+	}
+
 	// Add support for MultiNamespace set in WATCH_NAMESPACE (e.g ns1,ns2)
 	// Note that this is not intended to be used for excluding namespaces, this is better done via a Predicate
 	// Also note that you may face performance issues when using this with a high number of namespaces.
@@ -189,6 +201,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup all Webhooks
+	if viper.GetBool("enable-webhook") {
+		if err = webhook.AddToManager(mgr); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
 	if viper.GetBool("use-metrics-service") {
 		// Add the Metrics Service
 		addMetrics(ctx, cfg)
@@ -11,19 +11,62 @@ import (
 // BasicAuthSpec defines the desired state of BasicAuth
 type BasicAuthSpec struct {
 	// +optional
-	Length   string `json:"length,omitempty"`
-	Username string `json:"username"`
+	Length string `json:"length,omitempty"`
+	// Username is the user of single-user credentials, defaults to admin. It must not be set if Users are given.
+	// +optional
+	Username string `json:"username,omitempty"`
+	// Users are the users of multi-user credentials. The auth field holds a htpasswd entry per user and the password
+	// of each user is stored in the key <username>.password.
+	// +optional
+	Users []BasicAuthUser `json:"users,omitempty"`
 	// +optional
 	Encoding string `json:"encoding,omitempty"`
+	// HashAlgorithm is the algorithm the password is hashed with in the auth field, one of bcrypt (default),
+	// sha512, apr1 and argon2id
+	// +optional
+	HashAlgorithm string `json:"hashAlgorithm,omitempty"`
+	// Cost is the cost of bcrypt hashes, defaults to 10
+	// +optional
+	Cost int `json:"cost,omitempty"`
 	// +optional
 	Data map[string]string `json:"data,omitempty"`
 	// +optional
 	ForceRegenerate bool `json:"forceRegenerate,omitempty"`
+	// +optional
+	Rotation *Rotation `json:"rotation,omitempty"`
+	// KeepPreviousFor is the duration the previous values of regenerated fields are kept for
+	// in keys ending in ".previous"
+	// +optional
+	KeepPreviousFor string `json:"keepPreviousFor,omitempty"`
+	// Templates map keys of the Secret to text/template templates rendering them from the other keys of the
+	// Secret, e.g. "postgres://app:{{ .password }}@db:5432/app". They are re-rendered whenever values change.
+	// +optional
+	Templates map[string]string `json:"templates,omitempty"`
+}
+
+// BasicAuthUser is a user of multi-user basic auth credentials
+type BasicAuthUser struct {
+	Username string `json:"username"`
+	// Password is a fixed password of the user, a password is generated if it is not set
+	// +optional
+	Password string `json:"password,omitempty"`
 }
 
 // BasicAuthStatus defines the observed state of BasicAuth
 type BasicAuthStatus struct {
 	Secret *v1.ObjectReference `json:"secret,omitempty"`
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// +optional
+	NextRotationTime *metav1.Time `json:"nextRotationTime,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	LastGeneratedTime *metav1.Time `json:"lastGeneratedTime,omitempty"`
+	// +optional
+	GeneratedFields []string `json:"generatedFields,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -83,3 +126,35 @@ func (in *BasicAuthStatus) GetSecret() *v1.ObjectReference {
 func (in *BasicAuthStatus) SetSecret(secret *v1.ObjectReference) {
 	in.Secret = secret
 }
+
+func (in *BasicAuthStatus) GetConditions() []Condition {
+	return in.Conditions
+}
+
+func (in *BasicAuthStatus) SetConditions(conditions []Condition) {
+	in.Conditions = conditions
+}
+
+func (in *BasicAuthStatus) SetObservedGeneration(generation int64) {
+	in.ObservedGeneration = generation
+}
+
+func (in *BasicAuthStatus) SetLastGeneratedTime(time *metav1.Time) {
+	in.LastGeneratedTime = time
+}
+
+func (in *BasicAuthStatus) SetGeneratedFields(fields []string) {
+	in.GeneratedFields = fields
+}
+
+func (in *BasicAuthStatus) GetLastRotationTime() *metav1.Time {
+	return in.LastRotationTime
+}
+
+func (in *BasicAuthStatus) SetLastRotationTime(time *metav1.Time) {
+	in.LastRotationTime = time
+}
+
+func (in *BasicAuthStatus) SetNextRotationTime(time *metav1.Time) {
+	in.NextRotationTime = time
+}
@@ -0,0 +1,128 @@
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CertificateSpec defines the desired state of Certificate
+type CertificateSpec struct {
+	// CertificateAuthority is the name of the CertificateAuthority in the same namespace issuing the certificate
+	CertificateAuthority string `json:"certificateAuthority"`
+	// +optional
+	CommonName string `json:"commonName,omitempty"`
+	// +optional
+	SubjectAltNames []string `json:"subjectAltNames,omitempty"`
+	// +optional
+	Algorithm string `json:"algorithm,omitempty"`
+	// +optional
+	Length string `json:"length,omitempty"`
+	// +optional
+	Validity string `json:"validity,omitempty"`
+	// RenewBefore is the duration before expiry at which the certificate is reissued
+	// +optional
+	RenewBefore string `json:"renewBefore,omitempty"`
+	// ReusePrivateKey keeps the existing private key when the certificate is reissued
+	// +optional
+	ReusePrivateKey bool `json:"reusePrivateKey,omitempty"`
+	// +optional
+	Type string `json:"type,omitempty"`
+	// +optional
+	Data map[string]string `json:"data,omitempty"`
+	// +optional
+	ForceRegenerate bool `json:"forceRegenerate,omitempty"`
+}
+
+// CertificateStatus defines the observed state of Certificate
+type CertificateStatus struct {
+	Secret *v1.ObjectReference `json:"secret,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	LastGeneratedTime *metav1.Time `json:"lastGeneratedTime,omitempty"`
+	// +optional
+	GeneratedFields []string `json:"generatedFields,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Certificate is the Schema for the certificates API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=certificates,scope=Namespaced
+type Certificate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CertificateSpec   `json:"spec,omitempty"`
+	Status CertificateStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CertificateList contains a list of Certificate
+type CertificateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Certificate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Certificate{}, &CertificateList{})
+}
+
+func (in *CertificateList) GetTypeMeta() metav1.TypeMeta {
+	return in.TypeMeta
+}
+
+func (in *CertificateList) SetTypeMeta(meta metav1.TypeMeta) {
+	in.TypeMeta = meta
+}
+
+func (in *CertificateList) GetListMeta() metav1.ListMeta {
+	return in.ListMeta
+}
+
+func (in *CertificateList) SetListMeta(meta metav1.ListMeta) {
+	in.ListMeta = meta
+}
+
+func (in *Certificate) GetStatus() SecretStatus {
+	return &in.Status
+}
+
+func (in *Certificate) GetType() string {
+	if in.Spec.Type == "" {
+		return string(v1.SecretTypeTLS)
+	}
+	return in.Spec.Type
+}
+
+func (in *CertificateStatus) GetSecret() *v1.ObjectReference {
+	return in.Secret
+}
+
+func (in *CertificateStatus) SetSecret(secret *v1.ObjectReference) {
+	in.Secret = secret
+}
+
+func (in *CertificateStatus) GetConditions() []Condition {
+	return in.Conditions
+}
+
+func (in *CertificateStatus) SetConditions(conditions []Condition) {
+	in.Conditions = conditions
+}
+
+func (in *CertificateStatus) SetObservedGeneration(generation int64) {
+	in.ObservedGeneration = generation
+}
+
+func (in *CertificateStatus) SetLastGeneratedTime(time *metav1.Time) {
+	in.LastGeneratedTime = time
+}
+
+func (in *CertificateStatus) SetGeneratedFields(fields []string) {
+	in.GeneratedFields = fields
+}
@@ -0,0 +1,124 @@
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CertificateAuthoritySpec defines the desired state of CertificateAuthority
+type CertificateAuthoritySpec struct {
+	// +optional
+	CommonName string `json:"commonName,omitempty"`
+	// +optional
+	Algorithm string `json:"algorithm,omitempty"`
+	// +optional
+	Length string `json:"length,omitempty"`
+	// +optional
+	Validity string `json:"validity,omitempty"`
+	// RenewBefore is the duration before expiry at which the certificate is reissued
+	// +optional
+	RenewBefore string `json:"renewBefore,omitempty"`
+	// ReusePrivateKey keeps the existing private key when the certificate is reissued
+	// +optional
+	ReusePrivateKey bool `json:"reusePrivateKey,omitempty"`
+	// +optional
+	Type string `json:"type,omitempty"`
+	// +optional
+	Data map[string]string `json:"data,omitempty"`
+	// +optional
+	ForceRegenerate bool `json:"forceRegenerate,omitempty"`
+}
+
+// CertificateAuthorityStatus defines the observed state of CertificateAuthority
+type CertificateAuthorityStatus struct {
+	Secret *v1.ObjectReference `json:"secret,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	LastGeneratedTime *metav1.Time `json:"lastGeneratedTime,omitempty"`
+	// +optional
+	GeneratedFields []string `json:"generatedFields,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CertificateAuthority is the Schema for the certificateauthorities API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=certificateauthorities,scope=Namespaced
+type CertificateAuthority struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CertificateAuthoritySpec   `json:"spec,omitempty"`
+	Status CertificateAuthorityStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CertificateAuthorityList contains a list of CertificateAuthority
+type CertificateAuthorityList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CertificateAuthority `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CertificateAuthority{}, &CertificateAuthorityList{})
+}
+
+func (in *CertificateAuthorityList) GetTypeMeta() metav1.TypeMeta {
+	return in.TypeMeta
+}
+
+func (in *CertificateAuthorityList) SetTypeMeta(meta metav1.TypeMeta) {
+	in.TypeMeta = meta
+}
+
+func (in *CertificateAuthorityList) GetListMeta() metav1.ListMeta {
+	return in.ListMeta
+}
+
+func (in *CertificateAuthorityList) SetListMeta(meta metav1.ListMeta) {
+	in.ListMeta = meta
+}
+
+func (in *CertificateAuthority) GetStatus() SecretStatus {
+	return &in.Status
+}
+
+func (in *CertificateAuthority) GetType() string {
+	if in.Spec.Type == "" {
+		return string(v1.SecretTypeTLS)
+	}
+	return in.Spec.Type
+}
+
+func (in *CertificateAuthorityStatus) GetSecret() *v1.ObjectReference {
+	return in.Secret
+}
+
+func (in *CertificateAuthorityStatus) SetSecret(secret *v1.ObjectReference) {
+	in.Secret = secret
+}
+
+func (in *CertificateAuthorityStatus) GetConditions() []Condition {
+	return in.Conditions
+}
+
+func (in *CertificateAuthorityStatus) SetConditions(conditions []Condition) {
+	in.Conditions = conditions
+}
+
+func (in *CertificateAuthorityStatus) SetObservedGeneration(generation int64) {
+	in.ObservedGeneration = generation
+}
+
+func (in *CertificateAuthorityStatus) SetLastGeneratedTime(time *metav1.Time) {
+	in.LastGeneratedTime = time
+}
+
+func (in *CertificateAuthorityStatus) SetGeneratedFields(fields []string) {
+	in.GeneratedFields = fields
+}
@@ -0,0 +1,142 @@
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DockerRegistrySpec defines the desired state of DockerRegistry
+type DockerRegistrySpec struct {
+	// Server is the registry host the credentials are used for, e.g. registry.example.com:5000
+	Server string `json:"server"`
+	// Username is the user of the credentials, defaults to admin
+	// +optional
+	Username string `json:"username,omitempty"`
+	// +optional
+	Length string `json:"length,omitempty"`
+	// +optional
+	Encoding string `json:"encoding,omitempty"`
+	// Type is the type of the Secret, defaults to kubernetes.io/dockerconfigjson
+	// +optional
+	Type string `json:"type,omitempty"`
+	// +optional
+	Data map[string]string `json:"data,omitempty"`
+	// +optional
+	ForceRegenerate bool `json:"forceRegenerate,omitempty"`
+	// +optional
+	Rotation *Rotation `json:"rotation,omitempty"`
+	// Templates map keys of the Secret to text/template templates rendering them from the other keys of the
+	// Secret. They are re-rendered whenever values change.
+	// +optional
+	Templates map[string]string `json:"templates,omitempty"`
+}
+
+// DockerRegistryStatus defines the observed state of DockerRegistry
+type DockerRegistryStatus struct {
+	Secret *v1.ObjectReference `json:"secret,omitempty"`
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// +optional
+	NextRotationTime *metav1.Time `json:"nextRotationTime,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	LastGeneratedTime *metav1.Time `json:"lastGeneratedTime,omitempty"`
+	// +optional
+	GeneratedFields []string `json:"generatedFields,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DockerRegistry is the Schema for the dockerregistries API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=dockerregistries,scope=Namespaced
+type DockerRegistry struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DockerRegistrySpec   `json:"spec,omitempty"`
+	Status DockerRegistryStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DockerRegistryList contains a list of DockerRegistry
+type DockerRegistryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DockerRegistry `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DockerRegistry{}, &DockerRegistryList{})
+}
+
+func (in *DockerRegistryList) GetTypeMeta() metav1.TypeMeta {
+	return in.TypeMeta
+}
+
+func (in *DockerRegistryList) SetTypeMeta(meta metav1.TypeMeta) {
+	in.TypeMeta = meta
+}
+
+func (in *DockerRegistryList) GetListMeta() metav1.ListMeta {
+	return in.ListMeta
+}
+
+func (in *DockerRegistryList) SetListMeta(meta metav1.ListMeta) {
+	in.ListMeta = meta
+}
+
+func (in *DockerRegistry) GetStatus() SecretStatus {
+	return &in.Status
+}
+
+func (in *DockerRegistry) GetType() string {
+	if in.Spec.Type == "" {
+		return string(v1.SecretTypeDockerConfigJson)
+	}
+	return in.Spec.Type
+}
+
+func (in *DockerRegistryStatus) GetSecret() *v1.ObjectReference {
+	return in.Secret
+}
+
+func (in *DockerRegistryStatus) SetSecret(secret *v1.ObjectReference) {
+	in.Secret = secret
+}
+
+func (in *DockerRegistryStatus) GetConditions() []Condition {
+	return in.Conditions
+}
+
+func (in *DockerRegistryStatus) SetConditions(conditions []Condition) {
+	in.Conditions = conditions
+}
+
+func (in *DockerRegistryStatus) SetObservedGeneration(generation int64) {
+	in.ObservedGeneration = generation
+}
+
+func (in *DockerRegistryStatus) SetLastGeneratedTime(time *metav1.Time) {
+	in.LastGeneratedTime = time
+}
+
+func (in *DockerRegistryStatus) SetGeneratedFields(fields []string) {
+	in.GeneratedFields = fields
+}
+
+func (in *DockerRegistryStatus) GetLastRotationTime() *metav1.Time {
+	return in.LastRotationTime
+}
+
+func (in *DockerRegistryStatus) SetLastRotationTime(time *metav1.Time) {
+	in.LastRotationTime = time
+}
+
+func (in *DockerRegistryStatus) SetNextRotationTime(time *metav1.Time) {
+	in.NextRotationTime = time
+}
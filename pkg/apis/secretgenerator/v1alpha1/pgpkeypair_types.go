@@ -0,0 +1,144 @@
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PGPKeyPairSpec defines the desired state of PGPKeyPair
+type PGPKeyPairSpec struct {
+	// UserID identifies the owner of the key in the form "Name (Comment) <email>", defaults to the name of the cr
+	// +optional
+	UserID string `json:"userID,omitempty"`
+	// Algorithm is either ed25519 (the default), which generates an ed25519 key with a cv25519 encryption
+	// subkey, or rsa
+	// +optional
+	Algorithm string `json:"algorithm,omitempty"`
+	// Length is the size of RSA keys in bits
+	// +optional
+	Length string `json:"length,omitempty"`
+	// Expiry is the duration after which the key expires, e.g. "8760h". Keys do not expire if it is not set.
+	// +optional
+	Expiry string `json:"expiry,omitempty"`
+	// +optional
+	Passphrase *Passphrase `json:"passphrase,omitempty"`
+	// +optional
+	Type string `json:"type,omitempty"`
+	// +optional
+	Data map[string]string `json:"data,omitempty"`
+	// +optional
+	ForceRegenerate bool `json:"forceRegenerate,omitempty"`
+	// +optional
+	Rotation *Rotation `json:"rotation,omitempty"`
+	// Templates map keys of the Secret to text/template templates rendering them from the other keys of the
+	// Secret. They are re-rendered whenever values change.
+	// +optional
+	Templates map[string]string `json:"templates,omitempty"`
+}
+
+// PGPKeyPairStatus defines the observed state of PGPKeyPair
+type PGPKeyPairStatus struct {
+	Secret *v1.ObjectReference `json:"secret,omitempty"`
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// +optional
+	NextRotationTime *metav1.Time `json:"nextRotationTime,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	LastGeneratedTime *metav1.Time `json:"lastGeneratedTime,omitempty"`
+	// +optional
+	GeneratedFields []string `json:"generatedFields,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PGPKeyPair is the Schema for the pgpkeypairs API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=pgpkeypairs,scope=Namespaced
+type PGPKeyPair struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PGPKeyPairSpec   `json:"spec,omitempty"`
+	Status PGPKeyPairStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PGPKeyPairList contains a list of PGPKeyPair
+type PGPKeyPairList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PGPKeyPair `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PGPKeyPair{}, &PGPKeyPairList{})
+}
+
+func (in *PGPKeyPairList) GetTypeMeta() metav1.TypeMeta {
+	return in.TypeMeta
+}
+
+func (in *PGPKeyPairList) SetTypeMeta(meta metav1.TypeMeta) {
+	in.TypeMeta = meta
+}
+
+func (in *PGPKeyPairList) GetListMeta() metav1.ListMeta {
+	return in.ListMeta
+}
+
+func (in *PGPKeyPairList) SetListMeta(meta metav1.ListMeta) {
+	in.ListMeta = meta
+}
+
+func (in *PGPKeyPair) GetStatus() SecretStatus {
+	return &in.Status
+}
+
+func (in *PGPKeyPair) GetType() string {
+	return in.Spec.Type
+}
+
+func (in *PGPKeyPairStatus) GetSecret() *v1.ObjectReference {
+	return in.Secret
+}
+
+func (in *PGPKeyPairStatus) SetSecret(secret *v1.ObjectReference) {
+	in.Secret = secret
+}
+
+func (in *PGPKeyPairStatus) GetConditions() []Condition {
+	return in.Conditions
+}
+
+func (in *PGPKeyPairStatus) SetConditions(conditions []Condition) {
+	in.Conditions = conditions
+}
+
+func (in *PGPKeyPairStatus) SetObservedGeneration(generation int64) {
+	in.ObservedGeneration = generation
+}
+
+func (in *PGPKeyPairStatus) SetLastGeneratedTime(time *metav1.Time) {
+	in.LastGeneratedTime = time
+}
+
+func (in *PGPKeyPairStatus) SetGeneratedFields(fields []string) {
+	in.GeneratedFields = fields
+}
+
+func (in *PGPKeyPairStatus) GetLastRotationTime() *metav1.Time {
+	return in.LastRotationTime
+}
+
+func (in *PGPKeyPairStatus) SetLastRotationTime(time *metav1.Time) {
+	in.LastRotationTime = time
+}
+
+func (in *PGPKeyPairStatus) SetNextRotationTime(time *metav1.Time) {
+	in.NextRotationTime = time
+}
@@ -13,13 +13,40 @@ type SSHKeyPairSpec struct {
 	// +optional
 	Length string `json:"length,omitempty"`
 	// +optional
+	Algorithm string `json:"algorithm,omitempty"`
+	// +optional
 	PrivateKey string `json:"privateKey,omitempty"`
 	// +optional
+	PrivateKeyFormat string `json:"privateKeyFormat,omitempty"`
+	// +optional
+	Passphrase *Passphrase `json:"passphrase,omitempty"`
+	// +optional
 	Type string `json:"type,omitempty"`
 	// +optional
 	Data map[string]string `json:"data,omitempty"`
 	// +optional
 	ForceRegenerate bool `json:"forceRegenerate,omitempty"`
+	// +optional
+	Rotation *Rotation `json:"rotation,omitempty"`
+	// KeepPreviousFor is the duration the previous values of regenerated fields are kept for
+	// in keys ending in ".previous"
+	// +optional
+	KeepPreviousFor string `json:"keepPreviousFor,omitempty"`
+	// Templates map keys of the Secret to text/template templates rendering them from the other keys of the
+	// Secret, e.g. "postgres://app:{{ .password }}@db:5432/app". They are re-rendered whenever values change.
+	// +optional
+	Templates map[string]string `json:"templates,omitempty"`
+}
+
+// Passphrase defines where the passphrase protecting a generated private key is stored
+type Passphrase struct {
+	// FieldName is the key the passphrase is stored in, defaults to "passphrase"
+	// +optional
+	FieldName string `json:"fieldName,omitempty"`
+	// SecretName references another Secret in the same namespace the passphrase is read from.
+	// If not set, the passphrase is stored in the generated Secret and generated if empty.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
 }
 
 // SSHKeyPairStatus defines the observed state of SSHKeyPair
@@ -28,6 +55,18 @@ type SSHKeyPairStatus struct {
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
 	Secret *v1.ObjectReference `json:"secret,omitempty"`
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// +optional
+	NextRotationTime *metav1.Time `json:"nextRotationTime,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	LastGeneratedTime *metav1.Time `json:"lastGeneratedTime,omitempty"`
+	// +optional
+	GeneratedFields []string `json:"generatedFields,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -87,3 +126,35 @@ func (in *SSHKeyPairStatus) GetSecret() *v1.ObjectReference {
 func (in *SSHKeyPairStatus) SetSecret(secret *v1.ObjectReference) {
 	in.Secret = secret
 }
+
+func (in *SSHKeyPairStatus) GetConditions() []Condition {
+	return in.Conditions
+}
+
+func (in *SSHKeyPairStatus) SetConditions(conditions []Condition) {
+	in.Conditions = conditions
+}
+
+func (in *SSHKeyPairStatus) SetObservedGeneration(generation int64) {
+	in.ObservedGeneration = generation
+}
+
+func (in *SSHKeyPairStatus) SetLastGeneratedTime(time *metav1.Time) {
+	in.LastGeneratedTime = time
+}
+
+func (in *SSHKeyPairStatus) SetGeneratedFields(fields []string) {
+	in.GeneratedFields = fields
+}
+
+func (in *SSHKeyPairStatus) GetLastRotationTime() *metav1.Time {
+	return in.LastRotationTime
+}
+
+func (in *SSHKeyPairStatus) SetLastRotationTime(time *metav1.Time) {
+	in.LastRotationTime = time
+}
+
+func (in *SSHKeyPairStatus) SetNextRotationTime(time *metav1.Time) {
+	in.NextRotationTime = time
+}
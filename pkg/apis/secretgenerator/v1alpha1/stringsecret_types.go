@@ -17,17 +17,88 @@ type StringSecretSpec struct {
 	// +optional
 	ForceRegenerate bool    `json:"forceRegenerate,omitempty"`
 	Fields          []Field `json:"fields"`
+	// +optional
+	Rotation *Rotation `json:"rotation,omitempty"`
+	// KeepPreviousFor is the duration the previous values of regenerated fields are kept for
+	// in keys ending in ".previous"
+	// +optional
+	KeepPreviousFor string `json:"keepPreviousFor,omitempty"`
+	// Templates map keys of the Secret to text/template templates rendering them from the other keys of the
+	// Secret, e.g. "postgres://app:{{ .password }}@db:5432/app". They are re-rendered whenever values change.
+	// +optional
+	Templates map[string]string `json:"templates,omitempty"`
 }
 
 type Field struct {
 	FieldName string `json:"fieldName,omitempty"`
 	Encoding  string `json:"encoding,omitempty"`
 	Length    string `json:"length,omitempty"`
+	// Charset configures the characters of the value, if the encoding is "charset". Setting it without an
+	// encoding selects the "charset" encoding.
+	// +optional
+	Charset *Charset `json:"charset,omitempty"`
+	// Passphrase configures the words of the value, if the encoding is "passphrase". Setting it without an
+	// encoding selects the "passphrase" encoding.
+	// +optional
+	Passphrase *WordPassphrase `json:"passphrase,omitempty"`
+	// Hashes are hashes of the value stored in the keys <fieldName>.<hash>, one of scram-sha-256,
+	// mysql-caching-sha2, mysql-native and ldap-ssha. They are updated whenever the value changes.
+	// +optional
+	Hashes []string `json:"hashes,omitempty"`
+}
+
+// WordPassphrase configures values of the "passphrase" encoding, which consist of words of the EFF large wordlist
+type WordPassphrase struct {
+	// Words is the number of words, defaults to 6
+	// +optional
+	Words int `json:"words,omitempty"`
+	// Separator separates the words, defaults to "-"
+	// +optional
+	Separator string `json:"separator,omitempty"`
+	// Capitalize capitalizes the first letter of each word
+	// +optional
+	Capitalize bool `json:"capitalize,omitempty"`
+	// Digit appends a random digit to a random word
+	// +optional
+	Digit bool `json:"digit,omitempty"`
+}
+
+// Charset configures the characters values of the "charset" encoding are generated from
+type Charset struct {
+	// Alphabet are the characters values are generated from, defaults to letters, digits and symbols
+	// +optional
+	Alphabet string `json:"alphabet,omitempty"`
+	// Exclude are characters removed from the alphabet
+	// +optional
+	Exclude string `json:"exclude,omitempty"`
+	// ExcludeAmbiguous removes characters that are easily confused, such as 0 and O, from the alphabet
+	// +optional
+	ExcludeAmbiguous bool `json:"excludeAmbiguous,omitempty"`
+	// +optional
+	MinLowercase int `json:"minLowercase,omitempty"`
+	// +optional
+	MinUppercase int `json:"minUppercase,omitempty"`
+	// +optional
+	MinDigits int `json:"minDigits,omitempty"`
+	// +optional
+	MinSymbols int `json:"minSymbols,omitempty"`
 }
 
 // StringSecretStatus defines the observed state of StringSecret
 type StringSecretStatus struct {
 	Secret *v1.ObjectReference `json:"secret,omitempty"`
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// +optional
+	NextRotationTime *metav1.Time `json:"nextRotationTime,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	LastGeneratedTime *metav1.Time `json:"lastGeneratedTime,omitempty"`
+	// +optional
+	GeneratedFields []string `json:"generatedFields,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -86,3 +157,35 @@ func (in *StringSecretStatus) GetSecret() *v1.ObjectReference {
 func (in *StringSecretStatus) SetSecret(secret *v1.ObjectReference) {
 	in.Secret = secret
 }
+
+func (in *StringSecretStatus) GetConditions() []Condition {
+	return in.Conditions
+}
+
+func (in *StringSecretStatus) SetConditions(conditions []Condition) {
+	in.Conditions = conditions
+}
+
+func (in *StringSecretStatus) SetObservedGeneration(generation int64) {
+	in.ObservedGeneration = generation
+}
+
+func (in *StringSecretStatus) SetLastGeneratedTime(time *metav1.Time) {
+	in.LastGeneratedTime = time
+}
+
+func (in *StringSecretStatus) SetGeneratedFields(fields []string) {
+	in.GeneratedFields = fields
+}
+
+func (in *StringSecretStatus) GetLastRotationTime() *metav1.Time {
+	return in.LastRotationTime
+}
+
+func (in *StringSecretStatus) SetLastRotationTime(time *metav1.Time) {
+	in.LastRotationTime = time
+}
+
+func (in *StringSecretStatus) SetNextRotationTime(time *metav1.Time) {
+	in.NextRotationTime = time
+}
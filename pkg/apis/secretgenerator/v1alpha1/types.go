@@ -9,6 +9,51 @@ import (
 type SecretStatus interface {
 	GetSecret() *v1.ObjectReference
 	SetSecret(secret *v1.ObjectReference)
+	GetConditions() []Condition
+	SetConditions(conditions []Condition)
+	SetObservedGeneration(generation int64)
+	SetLastGeneratedTime(time *metav1.Time)
+	SetGeneratedFields(fields []string)
+}
+
+// ConditionType is the type of a status condition of a cr
+type ConditionType string
+
+const (
+	// ConditionReady indicates whether the Secret of a cr has been generated according to its current spec
+	ConditionReady ConditionType = "Ready"
+	// ConditionSecretOwnershipConflict indicates that a Secret with the name of a cr exists, but is not owned by it
+	ConditionSecretOwnershipConflict ConditionType = "SecretOwnershipConflict"
+	// ConditionInvalidSpec indicates that the spec of a cr is invalid
+	ConditionInvalidSpec ConditionType = "InvalidSpec"
+)
+
+// Condition describes the state of a cr at a certain point
+type Condition struct {
+	Type   ConditionType      `json:"type"`
+	Status v1.ConditionStatus `json:"status"`
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RotationStatus is implemented by the status of crs supporting scheduled rotation
+type RotationStatus interface {
+	GetLastRotationTime() *metav1.Time
+	SetLastRotationTime(time *metav1.Time)
+	SetNextRotationTime(time *metav1.Time)
+}
+
+// Rotation defines a schedule the generated values of a cr are regenerated on
+type Rotation struct {
+	// Schedule is a cron expression, e.g. "0 3 * * 0" for every Sunday at 3 AM
+	Schedule string `json:"schedule"`
+	// Timezone is the IANA name of the timezone the schedule is evaluated in, defaults to UTC
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
 }
 
 type ReconcilerState string
@@ -0,0 +1,135 @@
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WireGuardKeyPairSpec defines the desired state of WireGuardKeyPair
+type WireGuardKeyPairSpec struct {
+	// PrivateKey is a base64 encoded private key that is used instead of generating one
+	// +optional
+	PrivateKey string `json:"privateKey,omitempty"`
+	// PresharedKey enables the generation of a preshared key
+	// +optional
+	PresharedKey bool `json:"presharedKey,omitempty"`
+	// +optional
+	Type string `json:"type,omitempty"`
+	// +optional
+	Data map[string]string `json:"data,omitempty"`
+	// +optional
+	ForceRegenerate bool `json:"forceRegenerate,omitempty"`
+	// +optional
+	Rotation *Rotation `json:"rotation,omitempty"`
+	// Templates map keys of the Secret to text/template templates rendering them from the other keys of the
+	// Secret. They are re-rendered whenever values change.
+	// +optional
+	Templates map[string]string `json:"templates,omitempty"`
+}
+
+// WireGuardKeyPairStatus defines the observed state of WireGuardKeyPair
+type WireGuardKeyPairStatus struct {
+	Secret *v1.ObjectReference `json:"secret,omitempty"`
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// +optional
+	NextRotationTime *metav1.Time `json:"nextRotationTime,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	LastGeneratedTime *metav1.Time `json:"lastGeneratedTime,omitempty"`
+	// +optional
+	GeneratedFields []string `json:"generatedFields,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WireGuardKeyPair is the Schema for the wireguardkeypairs API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=wireguardkeypairs,scope=Namespaced
+type WireGuardKeyPair struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WireGuardKeyPairSpec   `json:"spec,omitempty"`
+	Status WireGuardKeyPairStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WireGuardKeyPairList contains a list of WireGuardKeyPair
+type WireGuardKeyPairList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WireGuardKeyPair `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WireGuardKeyPair{}, &WireGuardKeyPairList{})
+}
+
+func (in *WireGuardKeyPairList) GetTypeMeta() metav1.TypeMeta {
+	return in.TypeMeta
+}
+
+func (in *WireGuardKeyPairList) SetTypeMeta(meta metav1.TypeMeta) {
+	in.TypeMeta = meta
+}
+
+func (in *WireGuardKeyPairList) GetListMeta() metav1.ListMeta {
+	return in.ListMeta
+}
+
+func (in *WireGuardKeyPairList) SetListMeta(meta metav1.ListMeta) {
+	in.ListMeta = meta
+}
+
+func (in *WireGuardKeyPair) GetStatus() SecretStatus {
+	return &in.Status
+}
+
+func (in *WireGuardKeyPair) GetType() string {
+	return in.Spec.Type
+}
+
+func (in *WireGuardKeyPairStatus) GetSecret() *v1.ObjectReference {
+	return in.Secret
+}
+
+func (in *WireGuardKeyPairStatus) SetSecret(secret *v1.ObjectReference) {
+	in.Secret = secret
+}
+
+func (in *WireGuardKeyPairStatus) GetConditions() []Condition {
+	return in.Conditions
+}
+
+func (in *WireGuardKeyPairStatus) SetConditions(conditions []Condition) {
+	in.Conditions = conditions
+}
+
+func (in *WireGuardKeyPairStatus) SetObservedGeneration(generation int64) {
+	in.ObservedGeneration = generation
+}
+
+func (in *WireGuardKeyPairStatus) SetLastGeneratedTime(time *metav1.Time) {
+	in.LastGeneratedTime = time
+}
+
+func (in *WireGuardKeyPairStatus) SetGeneratedFields(fields []string) {
+	in.GeneratedFields = fields
+}
+
+func (in *WireGuardKeyPairStatus) GetLastRotationTime() *metav1.Time {
+	return in.LastRotationTime
+}
+
+func (in *WireGuardKeyPairStatus) SetLastRotationTime(time *metav1.Time) {
+	in.LastRotationTime = time
+}
+
+func (in *WireGuardKeyPairStatus) SetNextRotationTime(time *metav1.Time) {
+	in.NextRotationTime = time
+}
@@ -80,52 +80,723 @@ func (in *BasicAuthSpec) DeepCopyInto(out *BasicAuthSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]BasicAuthUser, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(Rotation)
+		**out = **in
+	}
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BasicAuthSpec.
+func (in *BasicAuthSpec) DeepCopy() *BasicAuthSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BasicAuthSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BasicAuthStatus) DeepCopyInto(out *BasicAuthStatus) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRotationTime != nil {
+		in, out := &in.NextRotationTime, &out.NextRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastGeneratedTime != nil {
+		in, out := &in.LastGeneratedTime, &out.LastGeneratedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.GeneratedFields != nil {
+		in, out := &in.GeneratedFields, &out.GeneratedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BasicAuthStatus.
+func (in *BasicAuthStatus) DeepCopy() *BasicAuthStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BasicAuthStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BasicAuthUser) DeepCopyInto(out *BasicAuthUser) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BasicAuthUser.
+func (in *BasicAuthUser) DeepCopy() *BasicAuthUser {
+	if in == nil {
+		return nil
+	}
+	out := new(BasicAuthUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Certificate) DeepCopyInto(out *Certificate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Certificate.
+func (in *Certificate) DeepCopy() *Certificate {
+	if in == nil {
+		return nil
+	}
+	out := new(Certificate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Certificate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateAuthority) DeepCopyInto(out *CertificateAuthority) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateAuthority.
+func (in *CertificateAuthority) DeepCopy() *CertificateAuthority {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateAuthority)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateAuthority) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateAuthorityList) DeepCopyInto(out *CertificateAuthorityList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CertificateAuthority, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateAuthorityList.
+func (in *CertificateAuthorityList) DeepCopy() *CertificateAuthorityList {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateAuthorityList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateAuthorityList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateAuthoritySpec) DeepCopyInto(out *CertificateAuthoritySpec) {
+	*out = *in
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateAuthoritySpec.
+func (in *CertificateAuthoritySpec) DeepCopy() *CertificateAuthoritySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateAuthoritySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateAuthorityStatus) DeepCopyInto(out *CertificateAuthorityStatus) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastGeneratedTime != nil {
+		in, out := &in.LastGeneratedTime, &out.LastGeneratedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.GeneratedFields != nil {
+		in, out := &in.GeneratedFields, &out.GeneratedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateAuthorityStatus.
+func (in *CertificateAuthorityStatus) DeepCopy() *CertificateAuthorityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateAuthorityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateList) DeepCopyInto(out *CertificateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Certificate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateList.
+func (in *CertificateList) DeepCopy() *CertificateList {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateSpec) DeepCopyInto(out *CertificateSpec) {
+	*out = *in
+	if in.SubjectAltNames != nil {
+		in, out := &in.SubjectAltNames, &out.SubjectAltNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateSpec.
+func (in *CertificateSpec) DeepCopy() *CertificateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateStatus) DeepCopyInto(out *CertificateStatus) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastGeneratedTime != nil {
+		in, out := &in.LastGeneratedTime, &out.LastGeneratedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.GeneratedFields != nil {
+		in, out := &in.GeneratedFields, &out.GeneratedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateStatus.
+func (in *CertificateStatus) DeepCopy() *CertificateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Charset) DeepCopyInto(out *Charset) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Charset.
+func (in *Charset) DeepCopy() *Charset {
+	if in == nil {
+		return nil
+	}
+	out := new(Charset)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DockerRegistry) DeepCopyInto(out *DockerRegistry) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DockerRegistry.
+func (in *DockerRegistry) DeepCopy() *DockerRegistry {
+	if in == nil {
+		return nil
+	}
+	out := new(DockerRegistry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DockerRegistry) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DockerRegistryList) DeepCopyInto(out *DockerRegistryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DockerRegistry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DockerRegistryList.
+func (in *DockerRegistryList) DeepCopy() *DockerRegistryList {
+	if in == nil {
+		return nil
+	}
+	out := new(DockerRegistryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DockerRegistryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DockerRegistrySpec) DeepCopyInto(out *DockerRegistrySpec) {
+	*out = *in
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(Rotation)
+		**out = **in
+	}
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DockerRegistrySpec.
+func (in *DockerRegistrySpec) DeepCopy() *DockerRegistrySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DockerRegistrySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DockerRegistryStatus) DeepCopyInto(out *DockerRegistryStatus) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRotationTime != nil {
+		in, out := &in.NextRotationTime, &out.NextRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastGeneratedTime != nil {
+		in, out := &in.LastGeneratedTime, &out.LastGeneratedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.GeneratedFields != nil {
+		in, out := &in.GeneratedFields, &out.GeneratedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DockerRegistryStatus.
+func (in *DockerRegistryStatus) DeepCopy() *DockerRegistryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DockerRegistryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Field) DeepCopyInto(out *Field) {
+	*out = *in
+	if in.Charset != nil {
+		in, out := &in.Charset, &out.Charset
+		*out = new(Charset)
+		**out = **in
+	}
+	if in.Passphrase != nil {
+		in, out := &in.Passphrase, &out.Passphrase
+		*out = new(WordPassphrase)
+		**out = **in
+	}
+	if in.Hashes != nil {
+		in, out := &in.Hashes, &out.Hashes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Field.
+func (in *Field) DeepCopy() *Field {
+	if in == nil {
+		return nil
+	}
+	out := new(Field)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PGPKeyPair) DeepCopyInto(out *PGPKeyPair) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BasicAuthSpec.
-func (in *BasicAuthSpec) DeepCopy() *BasicAuthSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGPKeyPair.
+func (in *PGPKeyPair) DeepCopy() *PGPKeyPair {
 	if in == nil {
 		return nil
 	}
-	out := new(BasicAuthSpec)
+	out := new(PGPKeyPair)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PGPKeyPair) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *BasicAuthStatus) DeepCopyInto(out *BasicAuthStatus) {
+func (in *PGPKeyPairList) DeepCopyInto(out *PGPKeyPairList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PGPKeyPair, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGPKeyPairList.
+func (in *PGPKeyPairList) DeepCopy() *PGPKeyPairList {
+	if in == nil {
+		return nil
+	}
+	out := new(PGPKeyPairList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PGPKeyPairList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PGPKeyPairSpec) DeepCopyInto(out *PGPKeyPairSpec) {
+	*out = *in
+	if in.Passphrase != nil {
+		in, out := &in.Passphrase, &out.Passphrase
+		*out = new(Passphrase)
+		**out = **in
+	}
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(Rotation)
+		**out = **in
+	}
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGPKeyPairSpec.
+func (in *PGPKeyPairSpec) DeepCopy() *PGPKeyPairSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PGPKeyPairSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PGPKeyPairStatus) DeepCopyInto(out *PGPKeyPairStatus) {
 	*out = *in
 	if in.Secret != nil {
 		in, out := &in.Secret, &out.Secret
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRotationTime != nil {
+		in, out := &in.NextRotationTime, &out.NextRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastGeneratedTime != nil {
+		in, out := &in.LastGeneratedTime, &out.LastGeneratedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.GeneratedFields != nil {
+		in, out := &in.GeneratedFields, &out.GeneratedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BasicAuthStatus.
-func (in *BasicAuthStatus) DeepCopy() *BasicAuthStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGPKeyPairStatus.
+func (in *PGPKeyPairStatus) DeepCopy() *PGPKeyPairStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(BasicAuthStatus)
+	out := new(PGPKeyPairStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Field) DeepCopyInto(out *Field) {
+func (in *Passphrase) DeepCopyInto(out *Passphrase) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Field.
-func (in *Field) DeepCopy() *Field {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Passphrase.
+func (in *Passphrase) DeepCopy() *Passphrase {
 	if in == nil {
 		return nil
 	}
-	out := new(Field)
+	out := new(Passphrase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Rotation) DeepCopyInto(out *Rotation) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rotation.
+func (in *Rotation) DeepCopy() *Rotation {
+	if in == nil {
+		return nil
+	}
+	out := new(Rotation)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -194,6 +865,11 @@ func (in *SSHKeyPairList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SSHKeyPairSpec) DeepCopyInto(out *SSHKeyPairSpec) {
 	*out = *in
+	if in.Passphrase != nil {
+		in, out := &in.Passphrase, &out.Passphrase
+		*out = new(Passphrase)
+		**out = **in
+	}
 	if in.Data != nil {
 		in, out := &in.Data, &out.Data
 		*out = make(map[string]string, len(*in))
@@ -201,6 +877,18 @@ func (in *SSHKeyPairSpec) DeepCopyInto(out *SSHKeyPairSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(Rotation)
+		**out = **in
+	}
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -222,6 +910,30 @@ func (in *SSHKeyPairStatus) DeepCopyInto(out *SSHKeyPairStatus) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRotationTime != nil {
+		in, out := &in.NextRotationTime, &out.NextRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastGeneratedTime != nil {
+		in, out := &in.LastGeneratedTime, &out.LastGeneratedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.GeneratedFields != nil {
+		in, out := &in.GeneratedFields, &out.GeneratedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -309,7 +1021,21 @@ func (in *StringSecretSpec) DeepCopyInto(out *StringSecretSpec) {
 	if in.Fields != nil {
 		in, out := &in.Fields, &out.Fields
 		*out = make([]Field, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(Rotation)
+		**out = **in
+	}
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
 	}
 	return
 }
@@ -332,6 +1058,30 @@ func (in *StringSecretStatus) DeepCopyInto(out *StringSecretStatus) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRotationTime != nil {
+		in, out := &in.NextRotationTime, &out.NextRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastGeneratedTime != nil {
+		in, out := &in.LastGeneratedTime, &out.LastGeneratedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.GeneratedFields != nil {
+		in, out := &in.GeneratedFields, &out.GeneratedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -344,3 +1094,160 @@ func (in *StringSecretStatus) DeepCopy() *StringSecretStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireGuardKeyPair) DeepCopyInto(out *WireGuardKeyPair) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireGuardKeyPair.
+func (in *WireGuardKeyPair) DeepCopy() *WireGuardKeyPair {
+	if in == nil {
+		return nil
+	}
+	out := new(WireGuardKeyPair)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WireGuardKeyPair) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireGuardKeyPairList) DeepCopyInto(out *WireGuardKeyPairList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WireGuardKeyPair, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireGuardKeyPairList.
+func (in *WireGuardKeyPairList) DeepCopy() *WireGuardKeyPairList {
+	if in == nil {
+		return nil
+	}
+	out := new(WireGuardKeyPairList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WireGuardKeyPairList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireGuardKeyPairSpec) DeepCopyInto(out *WireGuardKeyPairSpec) {
+	*out = *in
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(Rotation)
+		**out = **in
+	}
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireGuardKeyPairSpec.
+func (in *WireGuardKeyPairSpec) DeepCopy() *WireGuardKeyPairSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WireGuardKeyPairSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireGuardKeyPairStatus) DeepCopyInto(out *WireGuardKeyPairStatus) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRotationTime != nil {
+		in, out := &in.NextRotationTime, &out.NextRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastGeneratedTime != nil {
+		in, out := &in.LastGeneratedTime, &out.LastGeneratedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.GeneratedFields != nil {
+		in, out := &in.GeneratedFields, &out.GeneratedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireGuardKeyPairStatus.
+func (in *WireGuardKeyPairStatus) DeepCopy() *WireGuardKeyPairStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WireGuardKeyPairStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WordPassphrase) DeepCopyInto(out *WordPassphrase) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WordPassphrase.
+func (in *WordPassphrase) DeepCopy() *WordPassphrase {
+	if in == nil {
+		return nil
+	}
+	out := new(WordPassphrase)
+	in.DeepCopyInto(out)
+	return out
+}
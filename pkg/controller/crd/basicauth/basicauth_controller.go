@@ -2,12 +2,14 @@ package basicauth
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -25,6 +27,17 @@ import (
 var log = logf.Log.WithName("controller_basicauth_secret")
 var reqLogger logr.Logger
 
+// generatedFields are the keys of the Secret that are generated by the controller for single-user credentials
+var generatedFields = []string{secret.FieldBasicAuthIngress, secret.FieldBasicAuthUsername, secret.FieldBasicAuthPassword}
+
+// generatedFieldsOf returns the keys of the Secret that are generated by the controller for instance
+func generatedFieldsOf(instance *v1alpha1.BasicAuth) []string {
+	if len(instance.Spec.Users) > 0 {
+		return secret.BasicAuthUsersFields(constraintsFromSpec(instance).Users)
+	}
+	return generatedFields
+}
+
 const Kind = "BasicAuth"
 
 // Add creates a new BasicAuth Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -35,14 +48,15 @@ func Add(mgr manager.Manager) error {
 
 // NewReconciler returns a new reconcile.Reconciler
 func NewReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileBasicAuth{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileBasicAuth{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor(secret.EventSource)}
 }
 
 type ReconcileBasicAuth struct {
 	// This Client, initialized using mgr.Client() above, is a split Client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -80,9 +94,24 @@ func (r *ReconcileBasicAuth) Reconcile(request reconcile.Request) (reconcile.Res
 		return crd.CheckError(err)
 	}
 
+	c := crd.Client{Client: r.client, Recorder: r.recorder}
+
+	if err = ValidateSpec(instance); err != nil {
+		reqLogger.Error(err, "invalid spec")
+		c.ClientUpdateFailedStatus(ctx, reqLogger, instance, crd.ReasonInvalidSpec, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	return c.ClientReconcileOrUpdateFailedStatus(ctx, reqLogger, instance, func() (reconcile.Result, error) {
+		return r.reconcileSecret(ctx, request, instance, reqLogger)
+	})
+}
+
+// reconcileSecret creates the Secret of instance or updates the existing one
+func (r *ReconcileBasicAuth) reconcileSecret(ctx context.Context, request reconcile.Request, instance *v1alpha1.BasicAuth, reqLogger logr.Logger) (reconcile.Result, error) {
 	// attempt to fetch secret object described by this BasicAuth
 	existing := &v1.Secret{}
-	err = r.client.Get(ctx, request.NamespacedName, existing)
+	err := r.client.Get(ctx, request.NamespacedName, existing)
 	if errors.IsNotFound(err) {
 		// secret not found, create new one
 		return r.createNewSecret(ctx, instance, reqLogger)
@@ -101,32 +130,72 @@ func (r *ReconcileBasicAuth) updateSecret(ctx context.Context, instance *v1alpha
 	existingOwnerRefs := existing.OwnerReferences
 
 	if correct := crd.IsOwnedByCorrectCR(reqLogger, existingOwnerRefs, Kind); !correct {
+		c := crd.Client{Client: r.client, Recorder: r.recorder}
+		c.ClientUpdateOwnershipConflictStatus(ctx, reqLogger, instance, Kind)
 		return reconcile.Result{}, nil
 	}
 
-	username := instance.Spec.Username
-	length := instance.Spec.Length
-	encoding := instance.Spec.Encoding
 	regenerate := instance.Spec.ForceRegenerate
 	data := instance.Spec.Data
 
 	existingAuth := existing.Data[secret.FieldBasicAuthIngress]
 
+	rotate, nextRotation, err := crd.CheckRotation(instance.Spec.Rotation, instance, &instance.Status)
+	if err != nil {
+		reqLogger.Error(err, "could not parse rotation schedule")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+	if rotate {
+		reqLogger.Info("rotation is due, regenerating values")
+	}
+
+	gracePeriod, err := secret.ParseGracePeriod(instance.Spec.KeepPreviousFor)
+	if err != nil {
+		reqLogger.Error(err, "could not parse grace period for previous values")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
 	targetSecret := existing.DeepCopy()
 
+	secret.PrunePreviousValues(targetSecret, generatedFieldsOf(instance))
+
 	c := crd.Client{Client: r.client}
 
-	if len(existingAuth) > 0 && !regenerate {
-		// auth is set and regeneration is not forced, only update new data fields
+	if len(instance.Spec.Users) > 0 {
+		if (regenerate || rotate) && gracePeriod > 0 && secret.KeepPreviousValues(targetSecret.Data, generatedFieldsOf(instance)...) {
+			secret.SetPreviousValuesExpiry(targetSecret, gracePeriod)
+		}
+
+		// passwords of existing users are kept unless regeneration is forced or rotation is due, users that were
+		// added get new passwords and passwords of removed users are deleted
+		err = secret.GenerateBasicAuthUsersData(reqLogger, constraintsFromSpec(instance), targetSecret.Data, regenerate || rotate)
+		if err != nil {
+			return reconcile.Result{RequeueAfter: time.Second * 30}, err
+		}
+		crd.UpdateData(data, targetSecret, regenerate)
+
+		return r.updateSecretAndScheduleRotation(ctx, c, existing, targetSecret, instance, nextRotation, reqLogger)
+	}
+
+	if len(existingAuth) > 0 && !regenerate && !rotate {
+		// auth is set and regeneration is not forced, only update new data fields and the hash of the password,
+		// if the hash algorithm changed
+		if _, err = secret.RehashBasicAuthData(reqLogger, constraintsFromSpec(instance), targetSecret.Data); err != nil {
+			return reconcile.Result{RequeueAfter: time.Second * 30}, err
+		}
 		crd.UpdateData(data, targetSecret, regenerate)
 
-		return c.ClientUpdateSecret(ctx, targetSecret, instance, r.scheme)
+		return r.updateSecretAndScheduleRotation(ctx, c, existing, targetSecret, instance, nextRotation, reqLogger)
 	}
 
-	// either auth is not set or regeneration is forced, create new values
+	// either auth is not set, regeneration is forced or rotation is due, create new values
+
+	if gracePeriod > 0 && secret.KeepPreviousValues(targetSecret.Data, secret.BasicAuthRegeneratedFields...) {
+		secret.SetPreviousValuesExpiry(targetSecret, gracePeriod)
+	}
 
 	// generate auth fields and populate targetSecret.Data with them
-	err := secret.GenerateBasicAuthData(reqLogger, &secret.BasicAuthConstraints{Length: length, Encoding: encoding, Username: username}, targetSecret.Data)
+	err = secret.GenerateBasicAuthData(reqLogger, constraintsFromSpec(instance), targetSecret.Data)
 	if err != nil {
 		return reconcile.Result{RequeueAfter: time.Second * 30}, err
 	}
@@ -134,15 +203,35 @@ func (r *ReconcileBasicAuth) updateSecret(ctx context.Context, instance *v1alpha
 	// add new/updated fields from crd spec
 	crd.UpdateData(data, targetSecret, regenerate)
 
-	return c.ClientUpdateSecret(ctx, targetSecret, instance, r.scheme)
+	return r.updateSecretAndScheduleRotation(ctx, c, existing, targetSecret, instance, nextRotation, reqLogger)
+}
+
+// updateSecretAndScheduleRotation updates targetSecret and the status of instance, triggers rollouts of workloads
+// if the data of existing changed and requeues the request for the next rotation or the expiry of previous values
+func (r *ReconcileBasicAuth) updateSecretAndScheduleRotation(ctx context.Context, c crd.Client, existing *v1.Secret, targetSecret *v1.Secret,
+	instance *v1alpha1.BasicAuth, nextRotation time.Duration, reqLogger logr.Logger) (reconcile.Result, error) {
+	if err := secret.RenderTemplates(instance.Spec.Templates, targetSecret.Data); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	fields := generatedFieldsOf(instance)
+	crd.SetGeneratedFields(instance, fields, existing.Data, targetSecret.Data)
+
+	res, err := c.ClientUpdateSecret(ctx, targetSecret, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, fields, existing.Data, targetSecret.Data)
+
+	c.ClientTriggerRollouts(ctx, reqLogger, instance, existing, targetSecret)
+
+	return reconcile.Result{RequeueAfter: secret.EarliestRequeue(nextRotation, secret.PreviousValuesExpireIn(targetSecret))}, nil
 }
 
 // createNewSecret creates a new basic auth secret from the provided values. The Secret's owner will be set
 // as the BasicAuth that is being reconciled and a reference to the Secret will be stored in the cr's status.
 func (r *ReconcileBasicAuth) createNewSecret(ctx context.Context, instance *v1alpha1.BasicAuth, reqLogger logr.Logger) (reconcile.Result, error) {
-	username := instance.Spec.Username
-	length := instance.Spec.Length
-	encoding := instance.Spec.Encoding
 	data := instance.Spec.Data
 
 	values := make(map[string][]byte)
@@ -151,13 +240,99 @@ func (r *ReconcileBasicAuth) createNewSecret(ctx context.Context, instance *v1al
 		values[key] = []byte(data[key])
 	}
 
+	nextRotation, err := crd.ScheduleRotation(instance.Spec.Rotation, instance, &instance.Status)
+	if err != nil {
+		reqLogger.Error(err, "could not parse rotation schedule")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
 	// generate auth fields and populate values with them
-	err := secret.GenerateBasicAuthData(reqLogger, &secret.BasicAuthConstraints{Length: length, Encoding: encoding, Username: username}, values)
+	if len(instance.Spec.Users) > 0 {
+		err = secret.GenerateBasicAuthUsersData(reqLogger, constraintsFromSpec(instance), values, true)
+	} else {
+		err = secret.GenerateBasicAuthData(reqLogger, constraintsFromSpec(instance), values)
+	}
 	if err != nil {
 		return reconcile.Result{RequeueAfter: time.Second * 30}, err
 	}
 
+	if err = secret.RenderTemplates(instance.Spec.Templates, values); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
 	c := crd.Client{Client: r.client}
 
-	return c.ClientCreateSecret(ctx, values, instance, r.scheme)
+	fields := generatedFieldsOf(instance)
+	crd.SetGeneratedFields(instance, fields, nil, values)
+
+	res, err := c.ClientCreateSecret(ctx, values, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, fields, nil, values)
+
+	return reconcile.Result{RequeueAfter: nextRotation}, nil
+}
+
+// constraintsFromSpec returns the constraints for credential generation described by the spec of instance
+func constraintsFromSpec(instance *v1alpha1.BasicAuth) *secret.BasicAuthConstraints {
+	return &secret.BasicAuthConstraints{
+		Length:        instance.Spec.Length,
+		Encoding:      instance.Spec.Encoding,
+		Username:      instance.Spec.Username,
+		HashAlgorithm: secret.HashAlgorithm(instance.Spec.HashAlgorithm),
+		Cost:          instance.Spec.Cost,
+		Users:         usersFromSpec(instance.Spec.Users),
+	}
+}
+
+// usersFromSpec returns the users of multi-user credentials described by users
+func usersFromSpec(users []v1alpha1.BasicAuthUser) []secret.BasicAuthUser {
+	if len(users) == 0 {
+		return nil
+	}
+
+	result := make([]secret.BasicAuthUser, len(users))
+	for i, user := range users {
+		result[i] = secret.BasicAuthUser{Username: user.Username, Password: user.Password}
+	}
+
+	return result
+}
+
+// ValidateSpec checks whether the Secret described by the spec of instance can be generated
+func ValidateSpec(instance *v1alpha1.BasicAuth) error {
+	if err := constraintsFromSpec(instance).Validate(); err != nil {
+		return err
+	}
+
+	if err := crd.ValidateRotation(instance.Spec.Rotation); err != nil {
+		return err
+	}
+
+	if _, err := secret.ParseGracePeriod(instance.Spec.KeepPreviousFor); err != nil {
+		return err
+	}
+
+	fields := generatedFieldsOf(instance)
+	if len(instance.Spec.Users) > 0 {
+		// the passwords of users would be overwritten by data
+		for key := range instance.Spec.Data {
+			if contains(fields, key) {
+				return fmt.Errorf("key %s of data is generated and must not be set", key)
+			}
+		}
+	}
+
+	return crd.ValidateTemplates(instance.Spec.Templates, instance.Spec.Data, fields)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
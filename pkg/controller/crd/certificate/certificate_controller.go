@@ -0,0 +1,309 @@
+package certificate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/certificateauthority"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+var log = logf.Log.WithName("controller_certificate_secret")
+var reqLogger logr.Logger
+
+// generatedFields are the keys of the Secret that are generated by the controller
+var generatedFields = []string{secret.SecretFieldTLSCertificate, secret.SecretFieldTLSPrivateKey, secret.SecretFieldTLSCA}
+
+const Kind = "Certificate"
+
+// Add creates a new Certificate Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, NewReconciler(mgr))
+}
+
+// NewReconciler returns a new reconcile.Reconciler
+func NewReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileCertificate{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor(secret.EventSource)}
+}
+
+type ReconcileCertificate struct {
+	// This Client, initialized using mgr.Client() above, is a split Client
+	// that reads objects from the cache and writes to the apiserver
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("certificate-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource Certificate
+	err = c.Watch(&source.Kind{Type: &v1alpha1.Certificate{}}, &handler.EnqueueRequestForObject{}, crd.IgnoreStatusUpdatePredicate())
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to CA secrets, so that certificates are reissued once their CA has changed
+	err = c.Watch(&source.Kind{Type: &v1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+			return certificatesForCASecret(mgr.GetClient(), o)
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// certificatesForCASecret returns reconcile requests for all Certificates referencing the CertificateAuthority
+// owning the given Secret
+func certificatesForCASecret(c client.Client, o handler.MapObject) []reconcile.Request {
+	// CertificateAuthorities own the Secret of the same name, Secrets owned by another one are ignored
+	owner, ok := caOwnerReference(o.Meta.GetOwnerReferences())
+	if !ok || owner.Name != o.Meta.GetName() {
+		return nil
+	}
+
+	list := &v1alpha1.CertificateList{}
+	if err := c.List(context.Background(), list, client.InNamespace(o.Meta.GetNamespace())); err != nil {
+		log.Error(err, "could not list certificates", "namespace", o.Meta.GetNamespace())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, cert := range list.Items {
+		if cert.Spec.CertificateAuthority == owner.Name {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: cert.Namespace, Name: cert.Name},
+			})
+		}
+	}
+
+	return requests
+}
+
+// caOwnerReference returns the reference to the CertificateAuthority in ownerRefs. False is returned if there is none.
+func caOwnerReference(ownerRefs []metav1.OwnerReference) (metav1.OwnerReference, bool) {
+	for _, ref := range ownerRefs {
+		if ref.Kind == certificateauthority.Kind {
+			return ref, true
+		}
+	}
+	return metav1.OwnerReference{}, false
+}
+
+// isOwnedByCA checks whether ownerRefs reference the given CertificateAuthority
+func isOwnedByCA(ownerRefs []metav1.OwnerReference, ca *v1alpha1.CertificateAuthority) bool {
+	owner, ok := caOwnerReference(ownerRefs)
+
+	return ok && owner.Name == ca.Name && owner.UID == ca.UID
+}
+
+// Reconcile reads that state of the cluster for a Certificate object and makes changes based on the state read
+// and what is in the Certificate.Spec
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCertificate) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger = log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling Certificate")
+	ctx := context.Background()
+
+	// fetch the Certificate instance
+	instance := &v1alpha1.Certificate{}
+	err := r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		// if instance is not found don't requeue and don't return error, else requeue and return error
+		return crd.CheckError(err)
+	}
+
+	c := crd.Client{Client: r.client, Recorder: r.recorder}
+
+	if err = ValidateSpec(instance); err != nil {
+		reqLogger.Error(err, "invalid spec")
+		c.ClientUpdateFailedStatus(ctx, reqLogger, instance, crd.ReasonInvalidSpec, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	return c.ClientReconcileOrUpdateFailedStatus(ctx, reqLogger, instance, func() (reconcile.Result, error) {
+		return r.reconcileSecret(ctx, request, instance)
+	})
+}
+
+// reconcileSecret creates the Secret of instance or updates the existing one
+func (r *ReconcileCertificate) reconcileSecret(ctx context.Context, request reconcile.Request, instance *v1alpha1.Certificate) (reconcile.Result, error) {
+	caCert, caKey, err := r.getCAKeyPair(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "could not get certificate authority", "certificateAuthority", instance.Spec.CertificateAuthority)
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	existing := &v1.Secret{}
+	err = r.client.Get(ctx, request.NamespacedName, existing)
+	// secret not found, create new one
+	if apierrors.IsNotFound(err) {
+		return r.createNewSecret(ctx, instance, caCert, caKey)
+	}
+	// check for other errors
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return r.updateSecret(ctx, existing, instance, caCert, caKey)
+}
+
+// getCAKeyPair reads the PEM encoded certificate and private key of the CertificateAuthority referenced by instance
+// from its Secret
+func (r *ReconcileCertificate) getCAKeyPair(ctx context.Context, instance *v1alpha1.Certificate) ([]byte, []byte, error) {
+	if instance.Spec.CertificateAuthority == "" {
+		return nil, nil, fmt.Errorf("no certificate authority configured")
+	}
+
+	name := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Spec.CertificateAuthority}
+
+	ca := &v1alpha1.CertificateAuthority{}
+	if err := r.client.Get(ctx, name, ca); err != nil {
+		return nil, nil, err
+	}
+
+	caSecret := &v1.Secret{}
+	if err := r.client.Get(ctx, name, caSecret); err != nil {
+		return nil, nil, err
+	}
+
+	if !isOwnedByCA(caSecret.OwnerReferences, ca) {
+		return nil, nil, fmt.Errorf("secret %s is not owned by %s %s", caSecret.Name, certificateauthority.Kind, ca.Name)
+	}
+
+	caCert := caSecret.Data[secret.SecretFieldTLSCertificate]
+	caKey := caSecret.Data[secret.SecretFieldTLSPrivateKey]
+	if len(caCert) == 0 || len(caKey) == 0 {
+		return nil, nil, fmt.Errorf("certificate authority %s has not been generated yet", caSecret.Name)
+	}
+
+	return caCert, caKey, nil
+}
+
+// updateSecret attempts to update an existing Secret object with new values. Secret will only be updated,
+// if it is owned by a Certificate CR.
+func (r *ReconcileCertificate) updateSecret(ctx context.Context, existing *v1.Secret, instance *v1alpha1.Certificate,
+	caCert, caKey []byte) (reconcile.Result, error) {
+	// Check if Secret is owned by Certificate cr, otherwise do nothing
+	existingOwnerRefs := existing.OwnerReferences
+
+	if correct := crd.IsOwnedByCorrectCR(reqLogger, existingOwnerRefs, Kind); !correct {
+		c := crd.Client{Client: r.client, Recorder: r.recorder}
+		c.ClientUpdateOwnershipConflictStatus(ctx, reqLogger, instance, Kind)
+		return reconcile.Result{}, nil
+	}
+
+	regenerate := instance.Spec.ForceRegenerate
+	data := instance.Spec.Data
+
+	targetSecret := existing.DeepCopy()
+
+	crd.UpdateData(data, targetSecret, regenerate)
+
+	cons := ConstraintsFromSpec(instance)
+	err := secret.GenerateSignedTLSData(reqLogger, cons, caCert, caKey, regenerate, targetSecret.Data)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	c := crd.Client{Client: r.client}
+
+	crd.SetGeneratedFields(instance, generatedFields, existing.Data, targetSecret.Data)
+
+	res, err := c.ClientUpdateSecret(ctx, targetSecret, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, generatedFields, existing.Data, targetSecret.Data)
+
+	c.ClientTriggerRollouts(ctx, reqLogger, instance, existing, targetSecret)
+
+	return secret.RenewalResult(cons, targetSecret.Data), nil
+}
+
+// createNewSecret creates a new signed certificate from the provided values. The Secret's owner will be set
+// as the Certificate that is being reconciled and a reference to the Secret will be stored in the CR's status
+func (r *ReconcileCertificate) createNewSecret(ctx context.Context, instance *v1alpha1.Certificate,
+	caCert, caKey []byte) (reconcile.Result, error) {
+	values := make(map[string][]byte)
+
+	data := instance.Spec.Data
+
+	for key := range data {
+		values[key] = []byte(data[key])
+	}
+
+	cons := ConstraintsFromSpec(instance)
+	err := secret.GenerateSignedTLSData(reqLogger, cons, caCert, caKey, true, values)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	c := crd.Client{Client: r.client}
+
+	crd.SetGeneratedFields(instance, generatedFields, nil, values)
+
+	res, err := c.ClientCreateSecret(ctx, values, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, generatedFields, nil, values)
+
+	return secret.RenewalResult(cons, values), nil
+}
+
+// ConstraintsFromSpec returns the constraints for the certificate described by the spec of instance.
+// If no common name is set, the name of instance is used.
+func ConstraintsFromSpec(instance *v1alpha1.Certificate) *secret.TLSConstraints {
+	commonName := instance.Spec.CommonName
+	if commonName == "" {
+		commonName = instance.Name
+	}
+
+	return &secret.TLSConstraints{
+		CommonName: commonName,
+		SANs:       instance.Spec.SubjectAltNames,
+		Algorithm:  instance.Spec.Algorithm,
+		Length:     instance.Spec.Length,
+		Validity:   instance.Spec.Validity,
+
+		RenewBefore:     instance.Spec.RenewBefore,
+		ReusePrivateKey: instance.Spec.ReusePrivateKey,
+	}
+}
+
+// ValidateSpec checks whether the Secret described by the spec of instance can be generated
+func ValidateSpec(instance *v1alpha1.Certificate) error {
+	return ConstraintsFromSpec(instance).Validate()
+}
@@ -0,0 +1,245 @@
+package dockerregistry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+var log = logf.Log.WithName("controller_dockerregistry_secret")
+var reqLogger logr.Logger
+
+// generatedFields are the keys of the Secret that are generated by the controller
+var generatedFields = []string{
+	secret.FieldDockerConfigJSON,
+	secret.FieldDockerRegistryHtpasswd,
+	secret.FieldBasicAuthUsername,
+	secret.FieldBasicAuthPassword,
+}
+
+const Kind = "DockerRegistry"
+
+// Add creates a new DockerRegistry Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, NewReconciler(mgr))
+}
+
+// NewReconciler returns a new reconcile.Reconciler
+func NewReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileDockerRegistry{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor(secret.EventSource)}
+}
+
+type ReconcileDockerRegistry struct {
+	// This Client, initialized using mgr.Client() above, is a split Client
+	// that reads objects from the cache and writes to the apiserver
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("dockerregistry-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource DockerRegistry
+	err = c.Watch(&source.Kind{Type: &v1alpha1.DockerRegistry{}}, &handler.EnqueueRequestForObject{}, crd.IgnoreStatusUpdatePredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a DockerRegistry object and makes changes based on the state read
+// and what is in the DockerRegistry.Spec
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileDockerRegistry) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger = log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling DockerRegistry")
+	ctx := context.Background()
+
+	// fetch the DockerRegistry instance
+	instance := &v1alpha1.DockerRegistry{}
+	err := r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		// if instance is not found don't requeue and don't return error, else requeue and return error
+		return crd.CheckError(err)
+	}
+
+	c := crd.Client{Client: r.client, Recorder: r.recorder}
+
+	if err = ValidateSpec(instance); err != nil {
+		reqLogger.Error(err, "invalid spec")
+		c.ClientUpdateFailedStatus(ctx, reqLogger, instance, crd.ReasonInvalidSpec, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	return c.ClientReconcileOrUpdateFailedStatus(ctx, reqLogger, instance, func() (reconcile.Result, error) {
+		return r.reconcileSecret(ctx, request, instance)
+	})
+}
+
+// reconcileSecret creates the Secret of instance or updates the existing one
+func (r *ReconcileDockerRegistry) reconcileSecret(ctx context.Context, request reconcile.Request, instance *v1alpha1.DockerRegistry) (reconcile.Result, error) {
+	existing := &v1.Secret{}
+	err := r.client.Get(ctx, request.NamespacedName, existing)
+	// secret not found, create new one
+	if errors.IsNotFound(err) {
+		return r.createNewSecret(ctx, instance)
+	}
+	// check for other errors
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return r.updateSecret(ctx, instance, existing)
+}
+
+// updateSecret attempts to update an existing Secret object with new values. Secret will only be updated,
+// if it is owned by a DockerRegistry cr.
+func (r *ReconcileDockerRegistry) updateSecret(ctx context.Context, instance *v1alpha1.DockerRegistry, existing *v1.Secret) (reconcile.Result, error) {
+	existingOwnerRefs := existing.OwnerReferences
+
+	if correct := crd.IsOwnedByCorrectCR(reqLogger, existingOwnerRefs, Kind); !correct {
+		c := crd.Client{Client: r.client, Recorder: r.recorder}
+		c.ClientUpdateOwnershipConflictStatus(ctx, reqLogger, instance, Kind)
+		return reconcile.Result{}, nil
+	}
+
+	regenerate := instance.Spec.ForceRegenerate
+	data := instance.Spec.Data
+
+	rotate, nextRotation, err := crd.CheckRotation(instance.Spec.Rotation, instance, &instance.Status)
+	if err != nil {
+		reqLogger.Error(err, "could not parse rotation schedule")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+	if rotate {
+		reqLogger.Info("rotation is due, regenerating values")
+	}
+
+	targetSecret := existing.DeepCopy()
+
+	// the password is kept unless regeneration is forced or rotation is due, the docker config and htpasswd
+	// entry follow changes of the server and username
+	err = secret.GenerateDockerRegistryData(reqLogger, ConstraintsFromSpec(instance), regenerate || rotate, targetSecret.Data)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	// add new/updated fields from crd spec
+	crd.UpdateData(data, targetSecret, regenerate)
+
+	if err = secret.RenderTemplates(instance.Spec.Templates, targetSecret.Data); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	c := crd.Client{Client: r.client}
+
+	crd.SetGeneratedFields(instance, generatedFields, existing.Data, targetSecret.Data)
+
+	res, err := c.ClientUpdateSecret(ctx, targetSecret, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, generatedFields, existing.Data, targetSecret.Data)
+
+	c.ClientTriggerRollouts(ctx, reqLogger, instance, existing, targetSecret)
+
+	return reconcile.Result{RequeueAfter: nextRotation}, nil
+}
+
+// createNewSecret creates a new docker registry secret from the provided values. The Secret's owner will be set
+// as the DockerRegistry that is being reconciled and a reference to the Secret will be stored in the cr's status.
+func (r *ReconcileDockerRegistry) createNewSecret(ctx context.Context, instance *v1alpha1.DockerRegistry) (reconcile.Result, error) {
+	data := instance.Spec.Data
+
+	values := make(map[string][]byte)
+
+	for key := range data {
+		values[key] = []byte(data[key])
+	}
+
+	nextRotation, err := crd.ScheduleRotation(instance.Spec.Rotation, instance, &instance.Status)
+	if err != nil {
+		reqLogger.Error(err, "could not parse rotation schedule")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	err = secret.GenerateDockerRegistryData(reqLogger, ConstraintsFromSpec(instance), true, values)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	if err = secret.RenderTemplates(instance.Spec.Templates, values); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	c := crd.Client{Client: r.client}
+
+	crd.SetGeneratedFields(instance, generatedFields, nil, values)
+
+	res, err := c.ClientCreateSecret(ctx, values, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, generatedFields, nil, values)
+
+	return reconcile.Result{RequeueAfter: nextRotation}, nil
+}
+
+// ConstraintsFromSpec returns the constraints for the registry credentials described by the spec of instance
+func ConstraintsFromSpec(instance *v1alpha1.DockerRegistry) *secret.DockerRegistryConstraints {
+	return &secret.DockerRegistryConstraints{
+		Server:   instance.Spec.Server,
+		Username: instance.Spec.Username,
+		Encoding: instance.Spec.Encoding,
+		Length:   instance.Spec.Length,
+	}
+}
+
+// ValidateSpec checks whether the Secret described by the spec of instance can be generated
+func ValidateSpec(instance *v1alpha1.DockerRegistry) error {
+	if err := ConstraintsFromSpec(instance).Validate(); err != nil {
+		return err
+	}
+
+	if err := crd.ValidateRotation(instance.Spec.Rotation); err != nil {
+		return err
+	}
+
+	// the generated keys depend on each other, so none of them can be set by data
+	for _, field := range generatedFields {
+		if _, ok := instance.Spec.Data[field]; ok {
+			return fmt.Errorf("key %s of data is generated and must not be set", field)
+		}
+	}
+
+	return crd.ValidateTemplates(instance.Spec.Templates, instance.Spec.Data, generatedFields)
+}
@@ -0,0 +1,274 @@
+package pgpkeypair
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+var log = logf.Log.WithName("controller_pgp_secret")
+var reqLogger logr.Logger
+
+// generatedFields are the keys of the Secret that are generated by the controller
+var generatedFields = []string{secret.FieldPGPPrivateKey, secret.FieldPGPPublicKey, secret.FieldPGPFingerprint}
+
+const Kind = "PGPKeyPair"
+
+// Add creates a new PGPKeyPair Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, NewReconciler(mgr))
+}
+
+// NewReconciler returns a new reconcile.Reconciler
+func NewReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcilePGPKeyPair{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor(secret.EventSource)}
+}
+
+type ReconcilePGPKeyPair struct {
+	// This Client, initialized using mgr.Client() above, is a split Client
+	// that reads objects from the cache and writes to the apiserver
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("pgp-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource PGPKeyPair
+	err = c.Watch(&source.Kind{Type: &v1alpha1.PGPKeyPair{}}, &handler.EnqueueRequestForObject{}, crd.IgnoreStatusUpdatePredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a PGPKeyPair object and makes changes based on the state read
+// and what is in the PGPKeyPair.Spec
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcilePGPKeyPair) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger = log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling PGPKeyPair")
+	ctx := context.Background()
+
+	// fetch the PGPKeyPair instance
+	instance := &v1alpha1.PGPKeyPair{}
+	err := r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		// if instance is not found don't requeue and don't return error, else requeue and return error
+		return crd.CheckError(err)
+	}
+
+	c := crd.Client{Client: r.client, Recorder: r.recorder}
+
+	if err = ValidateSpec(instance); err != nil {
+		reqLogger.Error(err, "invalid spec")
+		c.ClientUpdateFailedStatus(ctx, reqLogger, instance, crd.ReasonInvalidSpec, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	return c.ClientReconcileOrUpdateFailedStatus(ctx, reqLogger, instance, func() (reconcile.Result, error) {
+		return r.reconcileSecret(ctx, request, instance)
+	})
+}
+
+// reconcileSecret creates the Secret of instance or updates the existing one
+func (r *ReconcilePGPKeyPair) reconcileSecret(ctx context.Context, request reconcile.Request, instance *v1alpha1.PGPKeyPair) (reconcile.Result, error) {
+	existing := &v1.Secret{}
+	err := r.client.Get(ctx, request.NamespacedName, existing)
+	// secret not found, create new one
+	if apierrors.IsNotFound(err) {
+		return r.createNewSecret(ctx, instance)
+	}
+	// check for other errors
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return r.updateSecret(ctx, existing, instance)
+}
+
+// updateSecret attempts to update an existing Secret object with new values. Secret will only be updated,
+// if it is owned by a PGPKeyPair cr.
+func (r *ReconcilePGPKeyPair) updateSecret(ctx context.Context, existing *v1.Secret, instance *v1alpha1.PGPKeyPair) (reconcile.Result, error) {
+	existingOwnerRefs := existing.OwnerReferences
+
+	if correct := crd.IsOwnedByCorrectCR(reqLogger, existingOwnerRefs, Kind); !correct {
+		c := crd.Client{Client: r.client, Recorder: r.recorder}
+		c.ClientUpdateOwnershipConflictStatus(ctx, reqLogger, instance, Kind)
+		return reconcile.Result{}, nil
+	}
+
+	regenerate := instance.Spec.ForceRegenerate
+	data := instance.Spec.Data
+
+	rotate, nextRotation, err := crd.CheckRotation(instance.Spec.Rotation, instance, &instance.Status)
+	if err != nil {
+		reqLogger.Error(err, "could not parse rotation schedule")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+	if rotate {
+		reqLogger.Info("rotation is due, regenerating values")
+	}
+
+	targetSecret := existing.DeepCopy()
+
+	crd.UpdateData(data, targetSecret, regenerate)
+
+	cons, err := r.constraintsFromSpec(ctx, instance)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	err = secret.GeneratePGPKeypairData(reqLogger, cons, regenerate || rotate, targetSecret.Data)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	if err = secret.RenderTemplates(instance.Spec.Templates, targetSecret.Data); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	c := crd.Client{Client: r.client}
+
+	crd.SetGeneratedFields(instance, generatedFields, existing.Data, targetSecret.Data)
+
+	res, err := c.ClientUpdateSecret(ctx, targetSecret, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, generatedFields, existing.Data, targetSecret.Data)
+
+	c.ClientTriggerRollouts(ctx, reqLogger, instance, existing, targetSecret)
+
+	return reconcile.Result{RequeueAfter: nextRotation}, nil
+}
+
+// createNewSecret creates a new OpenPGP key from the provided values. The Secret's owner will be set
+// as the PGPKeyPair that is being reconciled and a reference to the Secret will be stored in
+// the cr's status
+func (r *ReconcilePGPKeyPair) createNewSecret(ctx context.Context, instance *v1alpha1.PGPKeyPair) (reconcile.Result, error) {
+	values := make(map[string][]byte)
+
+	data := instance.Spec.Data
+
+	for key := range data {
+		values[key] = []byte(data[key])
+	}
+
+	nextRotation, err := crd.ScheduleRotation(instance.Spec.Rotation, instance, &instance.Status)
+	if err != nil {
+		reqLogger.Error(err, "could not parse rotation schedule")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	cons, err := r.constraintsFromSpec(ctx, instance)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	err = secret.GeneratePGPKeypairData(reqLogger, cons, true, values)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	if err = secret.RenderTemplates(instance.Spec.Templates, values); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	c := crd.Client{Client: r.client}
+
+	crd.SetGeneratedFields(instance, generatedFields, nil, values)
+
+	res, err := c.ClientCreateSecret(ctx, values, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, generatedFields, nil, values)
+
+	return reconcile.Result{RequeueAfter: nextRotation}, nil
+}
+
+// constraintsFromSpec returns the constraints for key generation described by the spec of instance.
+// If the passphrase is stored in another Secret, it is read from there.
+func (r *ReconcilePGPKeyPair) constraintsFromSpec(ctx context.Context, instance *v1alpha1.PGPKeyPair) (*secret.PGPKeypairConstraints, error) {
+	cons := ConstraintsFromSpec(instance)
+
+	passphrase := instance.Spec.Passphrase
+	if passphrase == nil {
+		return cons, nil
+	}
+
+	if passphrase.SecretName == "" {
+		cons.PassphraseField = passphrase.FieldName
+		if cons.PassphraseField == "" {
+			cons.PassphraseField = secret.SecretFieldPassphrase
+		}
+		return cons, nil
+	}
+
+	var err error
+	cons.Passphrase, err = secret.GetPassphraseFromSecret(ctx, r.client, instance.Namespace, passphrase.SecretName, passphrase.FieldName)
+	if err != nil {
+		reqLogger.Error(err, "could not read passphrase from secret", "passphraseSecret", passphrase.SecretName)
+		return nil, err
+	}
+
+	return cons, nil
+}
+
+// ConstraintsFromSpec returns the constraints for the key described by the spec of instance, without its passphrase.
+// The user ID defaults to the name of instance.
+func ConstraintsFromSpec(instance *v1alpha1.PGPKeyPair) *secret.PGPKeypairConstraints {
+	userID := instance.Spec.UserID
+	if userID == "" {
+		userID = instance.Name
+	}
+
+	return &secret.PGPKeypairConstraints{
+		UserID:    userID,
+		Algorithm: instance.Spec.Algorithm,
+		Length:    instance.Spec.Length,
+		Expiry:    instance.Spec.Expiry,
+	}
+}
+
+// ValidateSpec checks whether the Secret described by the spec of instance can be generated
+func ValidateSpec(instance *v1alpha1.PGPKeyPair) error {
+	if err := ConstraintsFromSpec(instance).Validate(); err != nil {
+		return err
+	}
+
+	if err := crd.ValidateRotation(instance.Spec.Rotation); err != nil {
+		return err
+	}
+
+	return crd.ValidateTemplates(instance.Spec.Templates, instance.Spec.Data, generatedFields)
+}
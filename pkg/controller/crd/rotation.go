@@ -0,0 +1,78 @@
+package crd
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+)
+
+// ParseRotationSchedule parses the cron expression of rotation. The schedule is evaluated in the timezone
+// of rotation, or UTC if no timezone is set.
+func ParseRotationSchedule(rotation *v1alpha1.Rotation) (cron.Schedule, error) {
+	timezone := rotation.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	return cron.ParseStandard("CRON_TZ=" + timezone + " " + rotation.Schedule)
+}
+
+// ValidateRotation checks whether the schedule of rotation can be parsed, if rotation is configured
+func ValidateRotation(rotation *v1alpha1.Rotation) error {
+	if rotation == nil || rotation.Schedule == "" {
+		return nil
+	}
+
+	_, err := ParseRotationSchedule(rotation)
+
+	return err
+}
+
+// CheckRotation determines whether the values generated for instance are due for rotation according to rotation.
+// The rotation times in status are updated accordingly and the duration until the next rotation is returned.
+// If no rotation is configured, false and a zero duration are returned.
+func CheckRotation(rotation *v1alpha1.Rotation, instance metav1.Object, status v1alpha1.RotationStatus) (bool, time.Duration, error) {
+	if rotation == nil || rotation.Schedule == "" {
+		status.SetNextRotationTime(nil)
+		return false, 0, nil
+	}
+
+	schedule, err := ParseRotationSchedule(rotation)
+	if err != nil {
+		return false, 0, err
+	}
+
+	now := time.Now()
+
+	// the first rotation is scheduled relative to the creation of instance
+	last := instance.GetCreationTimestamp().Time
+	if lastRotation := status.GetLastRotationTime(); lastRotation != nil {
+		last = lastRotation.Time
+	}
+	if last.IsZero() {
+		last = now
+	}
+
+	rotate := !now.Before(schedule.Next(last))
+	if rotate {
+		status.SetLastRotationTime(&metav1.Time{Time: now})
+		last = now
+	}
+
+	next := schedule.Next(last)
+	status.SetNextRotationTime(&metav1.Time{Time: next})
+
+	return rotate, next.Sub(now), nil
+}
+
+// ScheduleRotation schedules the first rotation of the values generated for a new Secret of instance. Freshly generated
+// values are never rotated, so only the rotation times in status are updated like by CheckRotation and the duration
+// until the next rotation is returned.
+func ScheduleRotation(rotation *v1alpha1.Rotation, instance metav1.Object, status v1alpha1.RotationStatus) (time.Duration, error) {
+	_, next, err := CheckRotation(rotation, instance, status)
+
+	return next, err
+}
@@ -8,6 +8,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -24,6 +25,9 @@ import (
 var log = logf.Log.WithName("controller_ssh_secret")
 var reqLogger logr.Logger
 
+// generatedFields are the keys of the Secret that are generated by the controller
+var generatedFields = []string{secret.SecretFieldPrivateKey, secret.SecretFieldPublicKey}
+
 const Kind = "SSHKeyPair"
 
 // Add creates a new SSHKeyPair Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -34,14 +38,15 @@ func Add(mgr manager.Manager) error {
 
 // NewReconciler returns a new reconcile.Reconciler
 func NewReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileSSHKeyPair{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileSSHKeyPair{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor(secret.EventSource)}
 }
 
 type ReconcileSSHKeyPair struct {
 	// This Client, initialized using mgr.Client() above, is a split Client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -79,8 +84,23 @@ func (r *ReconcileSSHKeyPair) Reconcile(request reconcile.Request) (reconcile.Re
 		return crd.CheckError(err)
 	}
 
+	c := crd.Client{Client: r.client, Recorder: r.recorder}
+
+	if err = ValidateSpec(instance); err != nil {
+		reqLogger.Error(err, "invalid spec")
+		c.ClientUpdateFailedStatus(ctx, reqLogger, instance, crd.ReasonInvalidSpec, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	return c.ClientReconcileOrUpdateFailedStatus(ctx, reqLogger, instance, func() (reconcile.Result, error) {
+		return r.reconcileSecret(ctx, request, instance)
+	})
+}
+
+// reconcileSecret creates the Secret of instance or updates the existing one
+func (r *ReconcileSSHKeyPair) reconcileSecret(ctx context.Context, request reconcile.Request, instance *v1alpha1.SSHKeyPair) (reconcile.Result, error) {
 	existing := &v1.Secret{}
-	err = r.client.Get(ctx, request.NamespacedName, existing)
+	err := r.client.Get(ctx, request.NamespacedName, existing)
 	// secret not found, create new one
 	if apierrors.IsNotFound(err) {
 		return r.createNewSecret(ctx, instance)
@@ -100,19 +120,41 @@ func (r *ReconcileSSHKeyPair) updateSecret(ctx context.Context, existing *v1.Sec
 	existingOwnerRefs := existing.OwnerReferences
 
 	if correct := crd.IsOwnedByCorrectCR(reqLogger, existingOwnerRefs, Kind); !correct {
+		c := crd.Client{Client: r.client, Recorder: r.recorder}
+		c.ClientUpdateOwnershipConflictStatus(ctx, reqLogger, instance, Kind)
 		return reconcile.Result{}, nil
 	}
 
 	// get config values from instance
-	length := instance.Spec.Length
 	regenerate := instance.Spec.ForceRegenerate
 	data := instance.Spec.Data
 	instancePrivateKey := instance.Spec.PrivateKey
 
 	existingPrivateKey := existing.Data[secret.SecretFieldPrivateKey]
 
+	rotate, nextRotation, err := crd.CheckRotation(instance.Spec.Rotation, instance, &instance.Status)
+	if err != nil {
+		reqLogger.Error(err, "could not parse rotation schedule")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+	if rotate {
+		reqLogger.Info("rotation is due, regenerating values")
+	}
+
+	gracePeriod, err := secret.ParseGracePeriod(instance.Spec.KeepPreviousFor)
+	if err != nil {
+		reqLogger.Error(err, "could not parse grace period for previous values")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
 	targetSecret := existing.DeepCopy()
 
+	secret.PrunePreviousValues(targetSecret, generatedFields)
+
+	if (regenerate || rotate) && gracePeriod > 0 && secret.KeepPreviousValues(targetSecret.Data, generatedFields...) {
+		secret.SetPreviousValuesExpiry(targetSecret, gracePeriod)
+	}
+
 	// if regeneration is forced or existing private key is empty use private key from spec
 	if len(instancePrivateKey) > 0 && (len(existingPrivateKey) == 0 || regenerate) {
 		targetSecret.Data[secret.SecretFieldPrivateKey] = []byte(instancePrivateKey)
@@ -120,14 +162,34 @@ func (r *ReconcileSSHKeyPair) updateSecret(ctx context.Context, existing *v1.Sec
 
 	crd.UpdateData(data, targetSecret, regenerate)
 
-	err := secret.GenerateSSHKeypairData(reqLogger, length, regenerate, targetSecret.Data)
+	cons, err := r.constraintsFromSpec(ctx, instance)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	err = secret.GenerateSSHKeypairData(reqLogger, cons, regenerate || rotate, targetSecret.Data)
 	if err != nil {
 		return reconcile.Result{RequeueAfter: time.Second * 30}, err
 	}
 
+	if err = secret.RenderTemplates(instance.Spec.Templates, targetSecret.Data); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
 	c := crd.Client{Client: r.client}
 
-	return c.ClientUpdateSecret(ctx, targetSecret, instance, r.scheme)
+	crd.SetGeneratedFields(instance, generatedFields, existing.Data, targetSecret.Data)
+
+	res, err := c.ClientUpdateSecret(ctx, targetSecret, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, generatedFields, existing.Data, targetSecret.Data)
+
+	c.ClientTriggerRollouts(ctx, reqLogger, instance, existing, targetSecret)
+
+	return reconcile.Result{RequeueAfter: secret.EarliestRequeue(nextRotation, secret.PreviousValuesExpireIn(targetSecret))}, nil
 }
 
 // createNewSecret creates a new ssh key pair from the provided values. The Secret's owner will be set
@@ -137,7 +199,6 @@ func (r *ReconcileSSHKeyPair) createNewSecret(ctx context.Context, instance *v1a
 	values := make(map[string][]byte)
 
 	// get config values from instance
-	length := instance.Spec.Length
 	data := instance.Spec.Data
 	instancePrivateKey := []byte(instance.Spec.PrivateKey)
 
@@ -147,12 +208,90 @@ func (r *ReconcileSSHKeyPair) createNewSecret(ctx context.Context, instance *v1a
 
 	values[secret.SecretFieldPrivateKey] = instancePrivateKey
 
-	err := secret.GenerateSSHKeypairData(reqLogger, length, false, values)
+	nextRotation, err := crd.ScheduleRotation(instance.Spec.Rotation, instance, &instance.Status)
+	if err != nil {
+		reqLogger.Error(err, "could not parse rotation schedule")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	cons, err := r.constraintsFromSpec(ctx, instance)
 	if err != nil {
 		return reconcile.Result{RequeueAfter: time.Second * 30}, err
 	}
 
+	err = secret.GenerateSSHKeypairData(reqLogger, cons, false, values)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	if err = secret.RenderTemplates(instance.Spec.Templates, values); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
 	c := crd.Client{Client: r.client}
 
-	return c.ClientCreateSecret(ctx, values, instance, r.scheme)
+	crd.SetGeneratedFields(instance, generatedFields, nil, values)
+
+	res, err := c.ClientCreateSecret(ctx, values, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, generatedFields, nil, values)
+
+	return reconcile.Result{RequeueAfter: nextRotation}, nil
+}
+
+// constraintsFromSpec returns the constraints for key pair generation described by the spec of instance.
+// If the passphrase is stored in another Secret, it is read from there.
+func (r *ReconcileSSHKeyPair) constraintsFromSpec(ctx context.Context, instance *v1alpha1.SSHKeyPair) (*secret.SSHKeypairConstraints, error) {
+	cons := &secret.SSHKeypairConstraints{
+		Length:    instance.Spec.Length,
+		Algorithm: instance.Spec.Algorithm,
+		Format:    instance.Spec.PrivateKeyFormat,
+	}
+
+	passphrase := instance.Spec.Passphrase
+	if passphrase == nil {
+		return cons, nil
+	}
+
+	if passphrase.SecretName == "" {
+		cons.PassphraseField = passphrase.FieldName
+		if cons.PassphraseField == "" {
+			cons.PassphraseField = secret.SecretFieldPassphrase
+		}
+		return cons, nil
+	}
+
+	var err error
+	cons.Passphrase, err = secret.GetPassphraseFromSecret(ctx, r.client, instance.Namespace, passphrase.SecretName, passphrase.FieldName)
+	if err != nil {
+		reqLogger.Error(err, "could not read passphrase from secret", "passphraseSecret", passphrase.SecretName)
+		return nil, err
+	}
+
+	return cons, nil
+}
+
+// ValidateSpec checks whether the Secret described by the spec of instance can be generated
+func ValidateSpec(instance *v1alpha1.SSHKeyPair) error {
+	cons := &secret.SSHKeypairConstraints{
+		Length:    instance.Spec.Length,
+		Algorithm: instance.Spec.Algorithm,
+		Format:    instance.Spec.PrivateKeyFormat,
+	}
+	if err := cons.Validate(); err != nil {
+		return err
+	}
+
+	if err := crd.ValidateRotation(instance.Spec.Rotation); err != nil {
+		return err
+	}
+
+	if _, err := secret.ParseGracePeriod(instance.Spec.KeepPreviousFor); err != nil {
+		return err
+	}
+
+	return crd.ValidateTemplates(instance.Spec.Templates, instance.Spec.Data, generatedFields)
 }
@@ -0,0 +1,135 @@
+package crd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+)
+
+// Reasons of the status conditions of crs
+const (
+	ReasonSecretGenerated         = "SecretGenerated"
+	ReasonGenerationFailed        = "GenerationFailed"
+	ReasonInvalidSpec             = "InvalidSpec"
+	ReasonValidSpec               = "ValidSpec"
+	ReasonSecretOwnershipConflict = "SecretOwnershipConflict"
+	ReasonSecretOwned             = "SecretOwned"
+)
+
+// GetCondition returns the condition of the given type from status, or nil if status has no such condition
+func GetCondition(status v1alpha1.SecretStatus, conditionType v1alpha1.ConditionType) *v1alpha1.Condition {
+	conditions := status.GetConditions()
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// SetCondition sets the condition of the given type in status. The transition time is only updated
+// if the status of the condition changes.
+func SetCondition(status v1alpha1.SecretStatus, conditionType v1alpha1.ConditionType, conditionStatus corev1.ConditionStatus,
+	reason, message string) {
+	if existing := GetCondition(status, conditionType); existing != nil {
+		if existing.Status != conditionStatus {
+			existing.LastTransitionTime = metav1.Now()
+		}
+		existing.Status = conditionStatus
+		existing.Reason = reason
+		existing.Message = message
+
+		return
+	}
+
+	status.SetConditions(append(status.GetConditions(), v1alpha1.Condition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}))
+}
+
+// SetGeneratedFields records fields as the fields generated for instance. The generation time is updated if any
+// of the fields has a different value in values than in existing, which is nil if the Secret did not exist before.
+func SetGeneratedFields(instance v1alpha1.APIObject, fields []string, existing, values map[string][]byte) {
+	status := instance.GetStatus()
+	status.SetGeneratedFields(fields)
+
+	for _, field := range fields {
+		if existing == nil || string(existing[field]) != string(values[field]) {
+			status.SetLastGeneratedTime(&metav1.Time{Time: time.Now()})
+			return
+		}
+	}
+}
+
+// setReadyStatus marks instance as ready, as its Secret matches the current spec
+func setReadyStatus(instance v1alpha1.APIObject) {
+	status := instance.GetStatus()
+	status.SetObservedGeneration(instance.GetGeneration())
+
+	SetCondition(status, v1alpha1.ConditionReady, corev1.ConditionTrue, ReasonSecretGenerated, "")
+	SetCondition(status, v1alpha1.ConditionInvalidSpec, corev1.ConditionFalse, ReasonValidSpec, "")
+	SetCondition(status, v1alpha1.ConditionSecretOwnershipConflict, corev1.ConditionFalse, ReasonSecretOwned, "")
+}
+
+// ClientUpdateFailedStatus marks instance as not ready for the given reason and updates its status. Conditions
+// matching the reason are set as well and a warning event is emitted, if the client has a recorder. Errors are only
+// logged, as the reconciliation has already failed.
+func (c *Client) ClientUpdateFailedStatus(ctx context.Context, logger logr.Logger, instance v1alpha1.APIObject, reason, message string) {
+	status := instance.GetStatus()
+	status.SetObservedGeneration(instance.GetGeneration())
+
+	SetCondition(status, v1alpha1.ConditionReady, corev1.ConditionFalse, reason, message)
+
+	switch reason {
+	case ReasonInvalidSpec:
+		SetCondition(status, v1alpha1.ConditionInvalidSpec, corev1.ConditionTrue, reason, message)
+	case ReasonSecretOwnershipConflict:
+		SetCondition(status, v1alpha1.ConditionInvalidSpec, corev1.ConditionFalse, ReasonValidSpec, "")
+		SetCondition(status, v1alpha1.ConditionSecretOwnershipConflict, corev1.ConditionTrue, reason, message)
+	default:
+		SetCondition(status, v1alpha1.ConditionInvalidSpec, corev1.ConditionFalse, ReasonValidSpec, "")
+		SetCondition(status, v1alpha1.ConditionSecretOwnershipConflict, corev1.ConditionFalse, ReasonSecretOwned, "")
+	}
+
+	if c.Recorder != nil {
+		c.Recorder.Event(instance, corev1.EventTypeWarning, reason, message)
+	}
+
+	if err := c.Status().Update(ctx, instance); err != nil {
+		logger.Error(err, "could not update status", "reason", reason)
+	}
+}
+
+// ClientReconcileOrUpdateFailedStatus runs reconcileSecret and marks instance as failed to generate its Secret if it
+// returns an error. A failed reconciliation may already have changed the status of instance, e.g. its rotation times,
+// so the failure is reported on a copy of instance taken before reconcileSecret was run.
+func (c *Client) ClientReconcileOrUpdateFailedStatus(ctx context.Context, logger logr.Logger, instance v1alpha1.APIObject,
+	reconcileSecret func() (reconcile.Result, error)) (reconcile.Result, error) {
+	fetched := instance.DeepCopyObject().(v1alpha1.APIObject)
+
+	res, err := reconcileSecret()
+	if err != nil {
+		c.ClientUpdateFailedStatus(ctx, logger, fetched, ReasonGenerationFailed, err.Error())
+	}
+
+	return res, err
+}
+
+// ClientUpdateOwnershipConflictStatus marks instance as not ready, as a Secret with its name exists,
+// but is not owned by instance
+func (c *Client) ClientUpdateOwnershipConflictStatus(ctx context.Context, logger logr.Logger, instance v1alpha1.APIObject, kind string) {
+	message := fmt.Sprintf("secret %s already exists and is not owned by a %s", instance.GetName(), kind)
+	c.ClientUpdateFailedStatus(ctx, logger, instance, ReasonSecretOwnershipConflict, message)
+}
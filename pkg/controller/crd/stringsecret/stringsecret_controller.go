@@ -2,12 +2,14 @@ package stringsecret
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -34,14 +36,15 @@ func Add(mgr manager.Manager) error {
 
 // NewReconciler returns a new reconcile.Reconciler
 func NewReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileStringSecret{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileStringSecret{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor(secret.EventSource)}
 }
 
 type ReconcileStringSecret struct {
 	// This Client, initialized using mgr.Client() above, is a split Client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -77,8 +80,23 @@ func (r *ReconcileStringSecret) Reconcile(request reconcile.Request) (reconcile.
 		return crd.CheckError(err)
 	}
 
+	c := crd.Client{Client: r.client, Recorder: r.recorder}
+
+	if err = ValidateSpec(instance); err != nil {
+		reqLogger.Error(err, "invalid spec")
+		c.ClientUpdateFailedStatus(ctx, reqLogger, instance, crd.ReasonInvalidSpec, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	return c.ClientReconcileOrUpdateFailedStatus(ctx, reqLogger, instance, func() (reconcile.Result, error) {
+		return r.reconcileSecret(ctx, request, instance)
+	})
+}
+
+// reconcileSecret creates the Secret of instance or updates the existing one
+func (r *ReconcileStringSecret) reconcileSecret(ctx context.Context, request reconcile.Request, instance *v1alpha1.StringSecret) (reconcile.Result, error) {
 	existing := &v1.Secret{}
-	err = r.client.Get(ctx, request.NamespacedName, existing)
+	err := r.client.Get(ctx, request.NamespacedName, existing)
 	// secret not found, create new one
 	if errors.IsNotFound(err) {
 		return r.createNewSecret(ctx, instance)
@@ -99,6 +117,8 @@ func (r *ReconcileStringSecret) updateSecret(ctx context.Context, instance *v1al
 	existingOwnerRefs := existing.OwnerReferences
 
 	if correct := crd.IsOwnedByCorrectCR(reqLogger, existingOwnerRefs, Kind); !correct {
+		c := crd.Client{Client: r.client, Recorder: r.recorder}
+		c.ClientUpdateOwnershipConflictStatus(ctx, reqLogger, instance, Kind)
 		return reconcile.Result{}, nil
 	}
 
@@ -106,20 +126,56 @@ func (r *ReconcileStringSecret) updateSecret(ctx context.Context, instance *v1al
 	regenerate := instance.Spec.ForceRegenerate
 	data := instance.Spec.Data
 
+	rotate, nextRotation, err := crd.CheckRotation(instance.Spec.Rotation, instance, &instance.Status)
+	if err != nil {
+		reqLogger.Error(err, "could not parse rotation schedule")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+	if rotate {
+		reqLogger.Info("rotation is due, regenerating values")
+	}
+
+	gracePeriod, err := secret.ParseGracePeriod(instance.Spec.KeepPreviousFor)
+	if err != nil {
+		reqLogger.Error(err, "could not parse grace period for previous values")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
 	targetSecret := existing.DeepCopy()
 
 	// update data values from spec
 	crd.UpdateData(data, targetSecret, regenerate)
 
+	secret.PrunePreviousValues(targetSecret, fieldNames(fields))
+
 	// Generate values from fields property
-	err := setValuesForFields(fields, regenerate, targetSecret.Data)
+	keptPrevious, err := setValuesForFields(fields, regenerate || rotate, gracePeriod > 0, targetSecret.Data)
 	if err != nil {
 		return reconcile.Result{RequeueAfter: time.Second * 30}, err
 	}
 
+	if keptPrevious {
+		secret.SetPreviousValuesExpiry(targetSecret, gracePeriod)
+	}
+
+	if err = secret.RenderTemplates(instance.Spec.Templates, targetSecret.Data); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
 	c := crd.Client{Client: r.client}
 
-	return c.ClientUpdateSecret(ctx, targetSecret, instance, r.scheme)
+	crd.SetGeneratedFields(instance, generatedFields(instance.Spec.Fields), existing.Data, targetSecret.Data)
+
+	res, err := c.ClientUpdateSecret(ctx, targetSecret, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, generatedFields(instance.Spec.Fields), existing.Data, targetSecret.Data)
+
+	c.ClientTriggerRollouts(ctx, reqLogger, instance, existing, targetSecret)
+
+	return reconcile.Result{RequeueAfter: secret.EarliestRequeue(nextRotation, secret.PreviousValuesExpireIn(targetSecret))}, nil
 }
 
 // createNewSecret creates a new string secret from the provided values. The Secret's owner will be set
@@ -135,40 +191,200 @@ func (r *ReconcileStringSecret) createNewSecret(ctx context.Context, instance *v
 		values[key] = []byte(data[key])
 	}
 
+	nextRotation, err := crd.ScheduleRotation(instance.Spec.Rotation, instance, &instance.Status)
+	if err != nil {
+		reqLogger.Error(err, "could not parse rotation schedule")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
 	// generate values from fields property
-	err := setValuesForFields(fields, true, values)
+	_, err = setValuesForFields(fields, true, false, values)
 	if err != nil {
 		return reconcile.Result{RequeueAfter: time.Second * 30}, err
 	}
 
+	if err = secret.RenderTemplates(instance.Spec.Templates, values); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
 	c := crd.Client{Client: r.client}
 
-	return c.ClientCreateSecret(ctx, values, instance, r.scheme)
+	crd.SetGeneratedFields(instance, generatedFields(instance.Spec.Fields), nil, values)
+
+	res, err := c.ClientCreateSecret(ctx, values, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, generatedFields(instance.Spec.Fields), nil, values)
+
+	return reconcile.Result{RequeueAfter: nextRotation}, nil
 }
 
 // setValuesForFields iterates over the given list of Fields and generates new random strings if the corresponding entry is empty or
-// regeneration is forced
-func setValuesForFields(fields []v1alpha1.Field, regenerate bool, values map[string][]byte) error {
+// regeneration is forced. If keepPrevious is true, regenerated values are kept in their companion keys, which is reported by the
+// returned bool.
+func setValuesForFields(fields []v1alpha1.Field, regenerate bool, keepPrevious bool, values map[string][]byte) (bool, error) {
+	keptPrevious := false
+
 	// generate only empty fields if regenerate wasn't set to true
 	for _, field := range fields {
 		if string(values[field.FieldName]) == "" || regenerate {
 			fieldLength, isByteLength, err := secret.ParseByteLength(secret.DefaultLength(), field.Length)
 			if err != nil {
 				reqLogger.Error(err, "could not parse length from map for new random string")
-				return err
+				return keptPrevious, err
 			}
-			encoding := field.Encoding
-			if encoding == "" {
-				encoding = secret.DefaultEncoding()
+			var randomString []byte
+			var randErr error
+			if encoding := fieldEncoding(field); encoding == secret.EncodingCharset {
+				randomString, randErr = secret.GenerateCharsetString(fieldLength, charsetConstraints(field.Charset))
+			} else if encoding == secret.EncodingPassphrase {
+				randomString, randErr = secret.GeneratePassphrase(passphraseConstraints(field.Passphrase))
+			} else {
+				randomString, randErr = secret.GenerateRandomString(fieldLength, encoding, isByteLength)
 			}
-			randomString, randErr := secret.GenerateRandomString(fieldLength, encoding, isByteLength)
 			if randErr != nil {
 				reqLogger.Error(randErr, "could not generate new random string")
-				return randErr
+				return keptPrevious, randErr
+			}
+			if keepPrevious && secret.KeepPreviousValue(values, field.FieldName) {
+				keptPrevious = true
 			}
 			values[field.FieldName] = randomString
 		}
+
+		// hashes are kept in sync with the value, whether it was generated or not
+		if err := secret.SetDerivedHashes(values, field.FieldName, derivedHashes(field.Hashes)); err != nil {
+			reqLogger.Error(err, "could not hash value", "field", field.FieldName)
+			return keptPrevious, err
+		}
 	}
 
-	return nil
+	return keptPrevious, nil
+}
+
+// fieldEncoding returns the encoding of the value of field. Fields configuring a charset or passphrase but no
+// encoding use the charset or passphrase encoding.
+func fieldEncoding(field v1alpha1.Field) string {
+	if field.Encoding != "" {
+		return field.Encoding
+	}
+	if field.Charset != nil {
+		return secret.EncodingCharset
+	}
+	if field.Passphrase != nil {
+		return secret.EncodingPassphrase
+	}
+
+	return secret.DefaultEncoding()
+}
+
+// charsetConstraints returns the constraints of charset strings described by charset, which may be nil
+func charsetConstraints(charset *v1alpha1.Charset) *secret.CharsetConstraints {
+	if charset == nil {
+		return &secret.CharsetConstraints{}
+	}
+
+	return &secret.CharsetConstraints{
+		Alphabet:         charset.Alphabet,
+		Exclude:          charset.Exclude,
+		ExcludeAmbiguous: charset.ExcludeAmbiguous,
+		MinLowercase:     charset.MinLowercase,
+		MinUppercase:     charset.MinUppercase,
+		MinDigits:        charset.MinDigits,
+		MinSymbols:       charset.MinSymbols,
+	}
+}
+
+// passphraseConstraints returns the constraints of passphrases described by passphrase, which may be nil
+func passphraseConstraints(passphrase *v1alpha1.WordPassphrase) *secret.PassphraseConstraints {
+	if passphrase == nil {
+		return &secret.PassphraseConstraints{}
+	}
+
+	return &secret.PassphraseConstraints{
+		Words:      passphrase.Words,
+		Separator:  passphrase.Separator,
+		Capitalize: passphrase.Capitalize,
+		Digit:      passphrase.Digit,
+	}
+}
+
+// derivedHashes returns the derived hashes named by hashes
+func derivedHashes(hashes []string) []secret.DerivedHash {
+	result := make([]secret.DerivedHash, len(hashes))
+	for i, hash := range hashes {
+		result[i] = secret.DerivedHash(hash)
+	}
+
+	return result
+}
+
+// fieldNames returns the names of the given fields
+func fieldNames(fields []v1alpha1.Field) []string {
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.FieldName)
+	}
+
+	return names
+}
+
+// generatedFields returns the keys generated for the given fields, which are their names and the keys of their hashes
+func generatedFields(fields []v1alpha1.Field) []string {
+	keys := fieldNames(fields)
+	for _, field := range fields {
+		keys = append(keys, secret.DerivedHashFields([]string{field.FieldName}, derivedHashes(field.Hashes))...)
+	}
+
+	return keys
+}
+
+// ValidateSpec checks whether the Secret described by the spec of instance can be generated
+func ValidateSpec(instance *v1alpha1.StringSecret) error {
+	if err := secret.EnsureUniqueness(fieldNames(instance.Spec.Fields)); err != nil {
+		return fmt.Errorf("invalid fields: %w", err)
+	}
+
+	for _, field := range instance.Spec.Fields {
+		length, _, err := secret.ParseByteLength(secret.DefaultLength(), field.Length)
+		if err != nil {
+			return fmt.Errorf("invalid length of field %s: %w", field.FieldName, err)
+		}
+		if err := secret.ValidateEncoding(field.Encoding); err != nil {
+			return fmt.Errorf("invalid encoding of field %s: %w", field.FieldName, err)
+		}
+		if field.Charset != nil && fieldEncoding(field) != secret.EncodingCharset {
+			return fmt.Errorf("charset of field %s requires the %s encoding", field.FieldName, secret.EncodingCharset)
+		}
+		if fieldEncoding(field) == secret.EncodingCharset {
+			if err := charsetConstraints(field.Charset).Validate(length); err != nil {
+				return fmt.Errorf("invalid charset of field %s: %w", field.FieldName, err)
+			}
+		}
+		if field.Passphrase != nil && fieldEncoding(field) != secret.EncodingPassphrase {
+			return fmt.Errorf("passphrase of field %s requires the %s encoding", field.FieldName, secret.EncodingPassphrase)
+		}
+		if err := passphraseConstraints(field.Passphrase).Validate(); err != nil {
+			return fmt.Errorf("invalid passphrase of field %s: %w", field.FieldName, err)
+		}
+		if err := secret.ValidateDerivedHashes(derivedHashes(field.Hashes)); err != nil {
+			return fmt.Errorf("invalid hashes of field %s: %w", field.FieldName, err)
+		}
+	}
+
+	if err := secret.EnsureUniqueness(generatedFields(instance.Spec.Fields)); err != nil {
+		return fmt.Errorf("invalid fields: %w", err)
+	}
+
+	if err := crd.ValidateRotation(instance.Spec.Rotation); err != nil {
+		return err
+	}
+
+	if _, err := secret.ParseGracePeriod(instance.Spec.KeepPreviousFor); err != nil {
+		return err
+	}
+
+	return crd.ValidateTemplates(instance.Spec.Templates, instance.Spec.Data, generatedFields(instance.Spec.Fields))
 }
@@ -2,6 +2,7 @@ package crd
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -9,6 +10,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/tools/reference"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -17,6 +19,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
 )
 
 // NewSecret creates an new Secret with given owner-info, type and data values
@@ -69,6 +72,8 @@ func IgnoreStatusUpdatePredicate() predicate.Predicate {
 
 type Client struct {
 	client.Client
+	// Recorder is used to emit events for failed reconciliations, if set
+	Recorder record.EventRecorder
 }
 
 // ClientCreateSecret creates a new Secret resource, uses the client to save it to the cluster and gets its resource
@@ -109,6 +114,20 @@ func (c *Client) ClientUpdateSecret(ctx context.Context, targetSecret *corev1.Se
 	return reconcile.Result{}, nil
 }
 
+// ClientTriggerRollouts triggers rollouts of the workloads referencing targetSecret, if its data differs from existing.
+// The Secrets of CRs carry no annotations, so workloads opt in either by themselves or through the annotations of
+// instance. Errors are only logged, as the changed data would not be detected again on a retry.
+func (c *Client) ClientTriggerRollouts(ctx context.Context, logger logr.Logger, instance v1alpha1.APIObject, existing *corev1.Secret, targetSecret *corev1.Secret) {
+	if reflect.DeepEqual(existing.Data, targetSecret.Data) {
+		return
+	}
+
+	optIn := secret.RolloutEnabled(instance.GetAnnotations()) || secret.RolloutEnabled(targetSecret.Annotations)
+	if err := secret.TriggerRolloutsWithOptIn(ctx, c, logger, targetSecret, optIn); err != nil {
+		logger.Error(err, "could not trigger rollouts of workloads referencing secret")
+	}
+}
+
 // getSecretRefAndSetStatus fetches the object reference for desiredSecret and writes it into the status of instance.
 func (c *Client) getSecretRefAndSetStatus(ctx context.Context, desiredSecret *corev1.Secret, instance v1alpha1.APIObject, scheme *runtime.Scheme) error {
 	// get Secret reference for status
@@ -119,6 +138,7 @@ func (c *Client) getSecretRefAndSetStatus(ctx context.Context, desiredSecret *co
 
 	status := instance.GetStatus()
 	status.SetSecret(stringRef)
+	setReadyStatus(instance)
 	if err = c.Status().Update(ctx, instance); err != nil {
 		return err
 	}
@@ -148,3 +168,14 @@ func IsOwnedByCorrectCR(logger logr.Logger, ownerRefs []metav1.OwnerReference, k
 
 	return false
 }
+
+// ValidateTemplates checks whether the templates of a cr can be rendered into its Secret without overwriting
+// the keys of data or the generated fields
+func ValidateTemplates(templates, data map[string]string, generatedFields []string) error {
+	reserved := append([]string{}, generatedFields...)
+	for key := range data {
+		reserved = append(reserved, key)
+	}
+
+	return secret.ValidateTemplates(templates, reserved)
+}
@@ -0,0 +1,243 @@
+package wireguardkeypair
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+var log = logf.Log.WithName("controller_wireguard_secret")
+var reqLogger logr.Logger
+
+const Kind = "WireGuardKeyPair"
+
+// Add creates a new WireGuardKeyPair Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, NewReconciler(mgr))
+}
+
+// NewReconciler returns a new reconcile.Reconciler
+func NewReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileWireGuardKeyPair{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor(secret.EventSource)}
+}
+
+type ReconcileWireGuardKeyPair struct {
+	// This Client, initialized using mgr.Client() above, is a split Client
+	// that reads objects from the cache and writes to the apiserver
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("wireguard-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource WireGuardKeyPair
+	err = c.Watch(&source.Kind{Type: &v1alpha1.WireGuardKeyPair{}}, &handler.EnqueueRequestForObject{}, crd.IgnoreStatusUpdatePredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a WireGuardKeyPair object and makes changes based on the state read
+// and what is in the WireGuardKeyPair.Spec
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileWireGuardKeyPair) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger = log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling WireGuardKeyPair")
+	ctx := context.Background()
+
+	// fetch the WireGuardKeyPair instance
+	instance := &v1alpha1.WireGuardKeyPair{}
+	err := r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		// if instance is not found don't requeue and don't return error, else requeue and return error
+		return crd.CheckError(err)
+	}
+
+	c := crd.Client{Client: r.client, Recorder: r.recorder}
+
+	if err = ValidateSpec(instance); err != nil {
+		reqLogger.Error(err, "invalid spec")
+		c.ClientUpdateFailedStatus(ctx, reqLogger, instance, crd.ReasonInvalidSpec, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	return c.ClientReconcileOrUpdateFailedStatus(ctx, reqLogger, instance, func() (reconcile.Result, error) {
+		return r.reconcileSecret(ctx, request, instance)
+	})
+}
+
+// reconcileSecret creates the Secret of instance or updates the existing one
+func (r *ReconcileWireGuardKeyPair) reconcileSecret(ctx context.Context, request reconcile.Request, instance *v1alpha1.WireGuardKeyPair) (reconcile.Result, error) {
+	existing := &v1.Secret{}
+	err := r.client.Get(ctx, request.NamespacedName, existing)
+	// secret not found, create new one
+	if apierrors.IsNotFound(err) {
+		return r.createNewSecret(ctx, instance)
+	}
+	// check for other errors
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return r.updateSecret(ctx, existing, instance)
+}
+
+// updateSecret attempts to update an existing Secret object with new values. Secret will only be updated,
+// if it is owned by a WireGuardKeyPair cr.
+func (r *ReconcileWireGuardKeyPair) updateSecret(ctx context.Context, existing *v1.Secret, instance *v1alpha1.WireGuardKeyPair) (reconcile.Result, error) {
+	existingOwnerRefs := existing.OwnerReferences
+
+	if correct := crd.IsOwnedByCorrectCR(reqLogger, existingOwnerRefs, Kind); !correct {
+		c := crd.Client{Client: r.client, Recorder: r.recorder}
+		c.ClientUpdateOwnershipConflictStatus(ctx, reqLogger, instance, Kind)
+		return reconcile.Result{}, nil
+	}
+
+	regenerate := instance.Spec.ForceRegenerate
+	data := instance.Spec.Data
+	instancePrivateKey := instance.Spec.PrivateKey
+
+	existingPrivateKey := existing.Data[secret.FieldWireGuardPrivateKey]
+
+	rotate, nextRotation, err := crd.CheckRotation(instance.Spec.Rotation, instance, &instance.Status)
+	if err != nil {
+		reqLogger.Error(err, "could not parse rotation schedule")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+	if rotate {
+		reqLogger.Info("rotation is due, regenerating values")
+	}
+
+	targetSecret := existing.DeepCopy()
+
+	// if regeneration is forced or existing private key is empty use private key from spec, its public key
+	// is derived again
+	if len(instancePrivateKey) > 0 && (len(existingPrivateKey) == 0 || regenerate) {
+		targetSecret.Data[secret.FieldWireGuardPrivateKey] = []byte(instancePrivateKey)
+		delete(targetSecret.Data, secret.FieldWireGuardPublicKey)
+	}
+
+	crd.UpdateData(data, targetSecret, regenerate)
+
+	// a private key from the spec is only replaced by a rotation
+	regenerate = (regenerate && len(instancePrivateKey) == 0) || rotate
+	err = secret.GenerateWireGuardKeypairData(reqLogger, constraintsFromSpec(instance), regenerate, targetSecret.Data)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	if err = secret.RenderTemplates(instance.Spec.Templates, targetSecret.Data); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	c := crd.Client{Client: r.client}
+
+	generatedFields := secret.WireGuardKeypairFields(constraintsFromSpec(instance))
+	crd.SetGeneratedFields(instance, generatedFields, existing.Data, targetSecret.Data)
+
+	res, err := c.ClientUpdateSecret(ctx, targetSecret, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, generatedFields, existing.Data, targetSecret.Data)
+
+	c.ClientTriggerRollouts(ctx, reqLogger, instance, existing, targetSecret)
+
+	return reconcile.Result{RequeueAfter: nextRotation}, nil
+}
+
+// createNewSecret creates a new WireGuard key pair from the provided values. The Secret's owner will be set
+// as the WireGuardKeyPair that is being reconciled and a reference to the Secret will be stored in
+// the cr's status
+func (r *ReconcileWireGuardKeyPair) createNewSecret(ctx context.Context, instance *v1alpha1.WireGuardKeyPair) (reconcile.Result, error) {
+	values := make(map[string][]byte)
+
+	data := instance.Spec.Data
+
+	for key := range data {
+		values[key] = []byte(data[key])
+	}
+
+	values[secret.FieldWireGuardPrivateKey] = []byte(instance.Spec.PrivateKey)
+
+	nextRotation, err := crd.ScheduleRotation(instance.Spec.Rotation, instance, &instance.Status)
+	if err != nil {
+		reqLogger.Error(err, "could not parse rotation schedule")
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	err = secret.GenerateWireGuardKeypairData(reqLogger, constraintsFromSpec(instance), false, values)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	if err = secret.RenderTemplates(instance.Spec.Templates, values); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	c := crd.Client{Client: r.client}
+
+	generatedFields := secret.WireGuardKeypairFields(constraintsFromSpec(instance))
+	crd.SetGeneratedFields(instance, generatedFields, nil, values)
+
+	res, err := c.ClientCreateSecret(ctx, values, instance, r.scheme)
+	if err != nil {
+		return res, err
+	}
+
+	secret.RecordGenerationEvents(r.recorder, instance, generatedFields, nil, values)
+
+	return reconcile.Result{RequeueAfter: nextRotation}, nil
+}
+
+// constraintsFromSpec returns the constraints for key pair generation described by the spec of instance
+func constraintsFromSpec(instance *v1alpha1.WireGuardKeyPair) *secret.WireGuardKeypairConstraints {
+	return &secret.WireGuardKeypairConstraints{
+		PresharedKey: instance.Spec.PresharedKey,
+	}
+}
+
+// ValidateSpec checks whether the Secret described by the spec of instance can be generated
+func ValidateSpec(instance *v1alpha1.WireGuardKeyPair) error {
+	if instance.Spec.PrivateKey != "" {
+		// the public key of the private key can only be derived from valid keys
+		if err := secret.CheckAndRegenWireGuardPublicKey(map[string][]byte{}, nil, []byte(instance.Spec.PrivateKey)); err != nil {
+			return err
+		}
+	}
+
+	if err := crd.ValidateRotation(instance.Spec.Rotation); err != nil {
+		return err
+	}
+
+	return crd.ValidateTemplates(instance.Spec.Templates, instance.Spec.Data, secret.WireGuardKeypairFields(constraintsFromSpec(instance)))
+}
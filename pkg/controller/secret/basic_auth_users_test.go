@@ -0,0 +1,124 @@
+package secret_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/basicauth"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+// htpasswdHashes returns the hashes of the users of the auth field of data
+func htpasswdHashes(t *testing.T, data map[string][]byte) map[string]string {
+	hashes := make(map[string]string)
+	for _, line := range strings.Split(string(data[secret.FieldBasicAuthIngress]), "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		require.Len(t, parts, 2, "invalid htpasswd line %s", line)
+		hashes[parts[0]] = parts[1]
+	}
+
+	return hashes
+}
+
+func TestGenerateBasicAuthUsersData(t *testing.T) {
+	cons := &secret.BasicAuthConstraints{
+		Users: []secret.BasicAuthUser{{Username: "alice"}, {Username: "bob", Password: "fixed"}},
+	}
+
+	data := map[string][]byte{}
+	require.NoError(t, secret.GenerateBasicAuthUsersData(logf.Log, cons, data, false))
+
+	hashes := htpasswdHashes(t, data)
+	require.Len(t, hashes, 2)
+	require.Equal(t, "fixed", string(data["bob.password"]))
+	require.Len(t, data["alice.password"], secret.DefaultLength())
+	require.True(t, secret.VerifyPassword(hashes["alice"], data["alice.password"]))
+	require.True(t, secret.VerifyPassword(hashes["bob"], data["bob.password"]))
+	require.True(t, strings.HasPrefix(string(data[secret.FieldBasicAuthIngress]), "alice:"))
+
+	alicePassword := string(data["alice.password"])
+
+	// users that are removed lose their password, untouched users keep theirs
+	cons.Users = []secret.BasicAuthUser{{Username: "alice"}, {Username: "carol"}}
+	require.NoError(t, secret.GenerateBasicAuthUsersData(logf.Log, cons, data, false))
+
+	updated := htpasswdHashes(t, data)
+	require.Len(t, updated, 2)
+	require.Equal(t, alicePassword, string(data["alice.password"]))
+	require.Equal(t, hashes["alice"], updated["alice"])
+	require.NotContains(t, data, "bob.password")
+	require.True(t, secret.VerifyPassword(updated["carol"], data["carol.password"]))
+
+	// regeneration replaces generated passwords
+	require.NoError(t, secret.GenerateBasicAuthUsersData(logf.Log, cons, data, true))
+	require.NotEqual(t, alicePassword, string(data["alice.password"]))
+	require.True(t, secret.VerifyPassword(htpasswdHashes(t, data)["alice"], data["alice.password"]))
+}
+
+func TestValidateBasicAuthUsers(t *testing.T) {
+	invalid := []*secret.BasicAuthConstraints{
+		{Username: "admin", Users: []secret.BasicAuthUser{{Username: "alice"}}},
+		{Users: []secret.BasicAuthUser{{Username: ""}}},
+		{Users: []secret.BasicAuthUser{{Username: "al:ice"}}},
+		{Users: []secret.BasicAuthUser{{Username: "al ice"}}},
+		{Users: []secret.BasicAuthUser{{Username: "alice"}, {Username: "alice"}}},
+	}
+
+	for _, cons := range invalid {
+		require.Error(t, cons.Validate(), "%+v", cons.Users)
+	}
+
+	require.NoError(t, (&secret.BasicAuthConstraints{Users: []secret.BasicAuthUser{{Username: "alice"}}}).Validate())
+}
+
+func TestControllerGenerateBasicAuthUsers(t *testing.T) {
+	in := newBasicAuthTestCR(v1alpha1.BasicAuthSpec{
+		Users: []v1alpha1.BasicAuthUser{{Username: "alice"}, {Username: "bob"}},
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileBasicAuthController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.Len(t, htpasswdHashes(t, out.Data), 2)
+	require.NotContains(t, out.Data, secret.FieldBasicAuthPassword)
+	alicePassword := string(out.Data["alice.password"])
+
+	cr := &v1alpha1.BasicAuth{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, cr))
+	require.ElementsMatch(t, []string{secret.FieldBasicAuthIngress, "alice.password", "bob.password"}, cr.Status.GeneratedFields)
+
+	cr.Spec.Users = []v1alpha1.BasicAuthUser{{Username: "alice"}, {Username: "carol", Password: "fixed"}}
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), cr))
+
+	doReconcileBasicAuthController(t, cr, false)
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	hashes := htpasswdHashes(t, out.Data)
+	require.Len(t, hashes, 2)
+	require.Equal(t, alicePassword, string(out.Data["alice.password"]))
+	require.Equal(t, "fixed", string(out.Data["carol.password"]))
+	require.NotContains(t, out.Data, "bob.password")
+	require.True(t, secret.VerifyPassword(hashes["carol"], []byte("fixed")))
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), cr))
+}
+
+func TestBasicAuthValidateSpecUsers(t *testing.T) {
+	in := newBasicAuthTestCR(v1alpha1.BasicAuthSpec{
+		Users: []v1alpha1.BasicAuthUser{{Username: "alice"}},
+		Data:  map[string]string{"alice.password": "other"},
+	}, "")
+	require.Error(t, basicauth.ValidateSpec(in))
+
+	in.Spec.Data = map[string]string{"other": "value"}
+	require.NoError(t, basicauth.ValidateSpec(in))
+}
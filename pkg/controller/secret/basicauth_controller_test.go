@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -294,3 +295,79 @@ func TestControllerDoNotTouchOtherSecrets(t *testing.T) {
 	}
 	require.NoError(t, mgr.GetClient().Delete(context.TODO(), testSecret))
 }
+
+func TestControllerRotateBasicAuth(t *testing.T) {
+	testSpec := v1alpha1.BasicAuthSpec{
+		Username: testUsername,
+		Rotation: &v1alpha1.Rotation{
+			Schedule: "* * * * *",
+		},
+	}
+	in := newBasicAuthTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileBasicAuthController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	// pretend the last rotation happened before the most recent scheduled run
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, in))
+	in.Status.LastRotationTime = &metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+	require.NoError(t, mgr.GetClient().Status().Update(context.TODO(), in))
+
+	doReconcileBasicAuthController(t, in, false)
+
+	outNew := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, outNew))
+
+	verifyBasicAuthSecretFromCR(t, in, outNew)
+
+	if reflect.DeepEqual(out.Data[secret.FieldBasicAuthPassword], outNew.Data[secret.FieldBasicAuthPassword]) {
+		t.Error("password has not been rotated")
+	}
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestControllerRegenerateBasicAuthKeepPrevious(t *testing.T) {
+	testSpec := v1alpha1.BasicAuthSpec{
+		Username:        testUsername,
+		KeepPreviousFor: "1h",
+	}
+	in := newBasicAuthTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileBasicAuthController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, in))
+	in.Spec.ForceRegenerate = true
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), in))
+
+	doReconcileBasicAuthController(t, in, false)
+
+	outNew := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, outNew))
+
+	require.NotEqual(t, out.Data[secret.FieldBasicAuthPassword], outNew.Data[secret.FieldBasicAuthPassword])
+	require.Equal(t, out.Data[secret.FieldBasicAuthPassword], outNew.Data[secret.FieldBasicAuthPassword+secret.SecretFieldPreviousSuffix])
+	require.Equal(t, out.Data[secret.FieldBasicAuthIngress], outNew.Data[secret.FieldBasicAuthIngress+secret.SecretFieldPreviousSuffix])
+	require.Contains(t, outNew.Annotations, secret.AnnotationSecretPreviousExpiry)
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
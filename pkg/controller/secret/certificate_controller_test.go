@@ -0,0 +1,291 @@
+package secret_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/certificate"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/certificateauthority"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+// newCertificateAuthorityTestCR returns a CertificateAuthority custom resource. If name is set to "", a uuid will be generated
+func newCertificateAuthorityTestCR(caSpec v1alpha1.CertificateAuthoritySpec, name string) *v1alpha1.CertificateAuthority {
+	if name == "" {
+		name = uuid.New().String()
+	}
+	cr := &v1alpha1.CertificateAuthority{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiVersion,
+			Kind:       certificateauthority.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+		},
+		Spec: caSpec,
+	}
+
+	return cr
+}
+
+// newCertificateTestCR returns a Certificate custom resource. If name is set to "", a uuid will be generated
+func newCertificateTestCR(certSpec v1alpha1.CertificateSpec, name string) *v1alpha1.Certificate {
+	if name == "" {
+		name = uuid.New().String()
+	}
+	cr := &v1alpha1.Certificate{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiVersion,
+			Kind:       certificate.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+		},
+		Spec: certSpec,
+	}
+
+	return cr
+}
+
+func doReconcileCertificateAuthorityController(t *testing.T, ca *v1alpha1.CertificateAuthority, isErr bool) {
+	rec := certificateauthority.NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: ca.Name, Namespace: ca.Namespace}}
+
+	res, err := rec.Reconcile(req)
+
+	if isErr {
+		require.Error(t, err)
+	} else {
+		require.NoError(t, err)
+	}
+	require.False(t, res.Requeue)
+}
+
+func doReconcileCertificateController(t *testing.T, cert *v1alpha1.Certificate, isErr bool) {
+	rec := certificate.NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: cert.Name, Namespace: cert.Namespace}}
+
+	res, err := rec.Reconcile(req)
+
+	if isErr {
+		require.Error(t, err)
+	} else {
+		require.NoError(t, err)
+	}
+	require.False(t, res.Requeue)
+}
+
+// createTestCA creates a CertificateAuthority, reconciles it and returns the generated Secret
+func createTestCA(t *testing.T) (*v1alpha1.CertificateAuthority, *corev1.Secret) {
+	ca := newCertificateAuthorityTestCR(v1alpha1.CertificateAuthoritySpec{}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), ca))
+
+	doReconcileCertificateAuthorityController(t, ca, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      ca.Name,
+		Namespace: ca.Namespace}, out))
+
+	return ca, out
+}
+
+// verifyCertificateSecret checks that the given Secret contains a key pair issued by the given CA certificate
+func verifyCertificateSecret(t *testing.T, out *corev1.Secret, caCertPEM []byte) *x509.Certificate {
+	if !bytes.Equal(out.Data[secret.SecretFieldTLSCA], caCertPEM) {
+		t.Errorf("secret does not contain the CA certificate in %s", secret.SecretFieldTLSCA)
+	}
+
+	_, err := tls.X509KeyPair(out.Data[secret.SecretFieldTLSCertificate], out.Data[secret.SecretFieldTLSPrivateKey])
+	require.NoError(t, err, "generated certificate and key do not match")
+
+	caCert, err := secret.ParseCertificatePEM(caCertPEM)
+	require.NoError(t, err)
+
+	cert, err := secret.ParseCertificatePEM(out.Data[secret.SecretFieldTLSCertificate])
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	_, err = cert.Verify(x509.VerifyOptions{Roots: roots})
+	require.NoError(t, err, "certificate has not been issued by CA")
+
+	return cert
+}
+
+func TestControllerGenerateCertificateAuthority(t *testing.T) {
+	ca, out := createTestCA(t)
+
+	for _, ref := range out.OwnerReferences {
+		require.Equal(t, certificateauthority.Kind, ref.Kind)
+	}
+	require.Equal(t, corev1.SecretTypeTLS, out.Type)
+
+	cert, err := secret.ParseCertificatePEM(out.Data[secret.SecretFieldTLSCertificate])
+	require.NoError(t, err)
+
+	require.True(t, cert.IsCA)
+	require.Equal(t, ca.Name, cert.Subject.CommonName)
+	require.Equal(t, out.Data[secret.SecretFieldTLSCertificate], out.Data[secret.SecretFieldTLSCA])
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), ca))
+}
+
+func TestControllerGenerateCertificate(t *testing.T) {
+	ca, caSecret := createTestCA(t)
+
+	in := newCertificateTestCR(v1alpha1.CertificateSpec{
+		CertificateAuthority: ca.Name,
+		SubjectAltNames:      []string{"example.default.svc"},
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileCertificateController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	for _, ref := range out.OwnerReferences {
+		require.Equal(t, certificate.Kind, ref.Kind)
+	}
+
+	cert := verifyCertificateSecret(t, out, caSecret.Data[secret.SecretFieldTLSCertificate])
+	require.Equal(t, in.Name, cert.Subject.CommonName)
+	require.Equal(t, []string{"example.default.svc"}, cert.DNSNames)
+	require.False(t, cert.IsCA)
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), ca))
+}
+
+func TestControllerCertificateMissingCA(t *testing.T) {
+	in := newCertificateTestCR(v1alpha1.CertificateSpec{
+		CertificateAuthority: "does-not-exist",
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileCertificateController(t, in, true)
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestControllerCertificateSecretOwnedByOtherCA(t *testing.T) {
+	ca, caSecret := createTestCA(t)
+
+	// the referenced CA has not been generated, but a Secret of its name is owned by another CA
+	other := newCertificateAuthorityTestCR(v1alpha1.CertificateAuthoritySpec{}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), other))
+
+	forged := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            other.Name,
+			Namespace:       other.Namespace,
+			Labels:          map[string]string{labelSecretGeneratorTest: "yes"},
+			OwnerReferences: caSecret.OwnerReferences,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: caSecret.Data,
+	}
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), forged))
+
+	in := newCertificateTestCR(v1alpha1.CertificateSpec{
+		CertificateAuthority: other.Name,
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileCertificateController(t, in, true)
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), other))
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), ca))
+}
+
+func TestControllerCertificateReissuedAfterCAChange(t *testing.T) {
+	ca, _ := createTestCA(t)
+
+	in := newCertificateTestCR(v1alpha1.CertificateSpec{
+		CertificateAuthority: ca.Name,
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileCertificateController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	// regenerate the CA
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      ca.Name,
+		Namespace: ca.Namespace}, ca))
+	ca.Spec.ForceRegenerate = true
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), ca))
+
+	doReconcileCertificateAuthorityController(t, ca, false)
+
+	caSecret := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      ca.Name,
+		Namespace: ca.Namespace}, caSecret))
+
+	doReconcileCertificateController(t, in, false)
+
+	outNew := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, outNew))
+
+	if bytes.Equal(out.Data[secret.SecretFieldTLSCertificate], outNew.Data[secret.SecretFieldTLSCertificate]) {
+		t.Error("certificate has not been reissued")
+	}
+
+	verifyCertificateSecret(t, outNew, caSecret.Data[secret.SecretFieldTLSCertificate])
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), ca))
+}
+
+func TestControllerCertificateRequeuesForRenewal(t *testing.T) {
+	ca, _ := createTestCA(t)
+
+	in := newCertificateTestCR(v1alpha1.CertificateSpec{
+		CertificateAuthority: ca.Name,
+		Validity:             "48h",
+		RenewBefore:          "24h",
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	rec := certificate.NewReconciler(mgr)
+	res, err := rec.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: in.Name, Namespace: in.Namespace}})
+	require.NoError(t, err)
+
+	if res.RequeueAfter > 24*time.Hour || res.RequeueAfter < 23*time.Hour {
+		t.Errorf("certificate is requeued after %s instead of shortly before expiry", res.RequeueAfter)
+	}
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), ca))
+}
@@ -0,0 +1,250 @@
+package secret
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// EncodingCharset is the encoding generating strings of characters drawn from a configurable alphabet
+const EncodingCharset = "charset"
+
+// Character classes of charset strings
+const (
+	CharsetLowercase = "abcdefghijklmnopqrstuvwxyz"
+	CharsetUppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	CharsetDigits    = "0123456789"
+	CharsetSymbols   = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+)
+
+// DefaultCharset is the alphabet of charset strings if no alphabet is configured
+const DefaultCharset = CharsetLowercase + CharsetUppercase + CharsetDigits + CharsetSymbols
+
+// AmbiguousCharacters are the characters that are easily confused with each other
+const AmbiguousCharacters = "0Oo1Il|`'\""
+
+// names of the character classes of the min-counts annotation
+const (
+	charsetClassLowercase = "lowercase"
+	charsetClassUppercase = "uppercase"
+	charsetClassDigits    = "digits"
+	charsetClassSymbols   = "symbols"
+)
+
+// CharsetConstraints configures the generation of charset strings
+type CharsetConstraints struct {
+	// Alphabet are the characters strings are generated from, defaults to DefaultCharset
+	Alphabet string
+	// Exclude are characters removed from the alphabet
+	Exclude string
+	// ExcludeAmbiguous removes AmbiguousCharacters from the alphabet
+	ExcludeAmbiguous bool
+	// MinLowercase, MinUppercase, MinDigits and MinSymbols are the minimum numbers of characters of each class
+	MinLowercase int
+	MinUppercase int
+	MinDigits    int
+	MinSymbols   int
+}
+
+// charsetClass is a class of characters of the alphabet that strings must contain a minimum number of
+type charsetClass struct {
+	name       string
+	min        int
+	characters []rune
+}
+
+// alphabet returns the characters strings are generated from, after removing excluded characters
+func (cons *CharsetConstraints) alphabet() []rune {
+	alphabet := cons.Alphabet
+	if alphabet == "" {
+		alphabet = DefaultCharset
+	}
+
+	exclude := cons.Exclude
+	if cons.ExcludeAmbiguous {
+		exclude += AmbiguousCharacters
+	}
+
+	seen := map[rune]bool{}
+	var out []rune
+	for _, c := range alphabet {
+		if seen[c] || strings.ContainsRune(exclude, c) {
+			continue
+		}
+		seen[c] = true
+		out = append(out, c)
+	}
+
+	return out
+}
+
+// classes returns the character classes of alphabet with their minimum counts
+func (cons *CharsetConstraints) classes(alphabet []rune) []charsetClass {
+	classes := []charsetClass{
+		{name: charsetClassLowercase, min: cons.MinLowercase},
+		{name: charsetClassUppercase, min: cons.MinUppercase},
+		{name: charsetClassDigits, min: cons.MinDigits},
+		{name: charsetClassSymbols, min: cons.MinSymbols},
+	}
+
+	for _, c := range alphabet {
+		switch {
+		case unicode.IsLower(c):
+			classes[0].characters = append(classes[0].characters, c)
+		case unicode.IsUpper(c):
+			classes[1].characters = append(classes[1].characters, c)
+		case unicode.IsDigit(c):
+			classes[2].characters = append(classes[2].characters, c)
+		default:
+			classes[3].characters = append(classes[3].characters, c)
+		}
+	}
+
+	return classes
+}
+
+// Validate checks whether strings of the given length can be generated with the given constraints
+func (cons *CharsetConstraints) Validate(length int) error {
+	alphabet := cons.alphabet()
+	if len(alphabet) == 0 {
+		return errors.New("alphabet is empty after removing excluded characters")
+	}
+
+	total := 0
+	for _, class := range cons.classes(alphabet) {
+		if class.min < 0 {
+			return fmt.Errorf("minimum number of %s must not be negative", class.name)
+		}
+		if class.min > 0 && len(class.characters) == 0 {
+			return fmt.Errorf("alphabet contains no %s", class.name)
+		}
+		total += class.min
+	}
+
+	if total > length {
+		return fmt.Errorf("minimum numbers of characters sum up to %d, which exceeds the length of %d", total, length)
+	}
+
+	return nil
+}
+
+// GenerateCharsetString generates a random string of length characters satisfying the given constraints.
+// Characters are sampled uniformly from crypto/rand.
+func GenerateCharsetString(length int, cons *CharsetConstraints) ([]byte, error) {
+	if err := cons.Validate(length); err != nil {
+		return nil, err
+	}
+
+	alphabet := cons.alphabet()
+
+	value := make([]rune, 0, length)
+	for _, class := range cons.classes(alphabet) {
+		for i := 0; i < class.min; i++ {
+			c, err := randomRune(class.characters)
+			if err != nil {
+				return nil, err
+			}
+			value = append(value, c)
+		}
+	}
+
+	for len(value) < length {
+		c, err := randomRune(alphabet)
+		if err != nil {
+			return nil, err
+		}
+		value = append(value, c)
+	}
+
+	// shuffle, so characters of the classes with minimum counts are not always at the beginning
+	for i := len(value) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		value[i], value[j] = value[j], value[i]
+	}
+
+	return []byte(string(value)), nil
+}
+
+// randomRune returns a uniformly chosen element of runes
+func randomRune(runes []rune) (rune, error) {
+	i, err := randomInt(len(runes))
+	if err != nil {
+		return 0, err
+	}
+
+	return runes[i], nil
+}
+
+// randomInt returns a uniformly chosen integer in [0, n)
+func randomInt(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(i.Int64()), nil
+}
+
+// GetCharsetConstraintsFromAnnotations returns the constraints of charset strings configured by annotations
+func GetCharsetConstraintsFromAnnotations(annotations map[string]string) (*CharsetConstraints, error) {
+	cons := &CharsetConstraints{
+		Alphabet: annotations[AnnotationCharset],
+		Exclude:  annotations[AnnotationCharsetExclude],
+	}
+
+	if value, ok := annotations[AnnotationCharsetNoAmbiguous]; ok {
+		excludeAmbiguous, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		cons.ExcludeAmbiguous = excludeAmbiguous
+	}
+
+	if value, ok := annotations[AnnotationCharsetMinCounts]; ok {
+		if err := parseCharsetMinCounts(cons, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return cons, nil
+}
+
+// parseCharsetMinCounts sets the minimum counts of cons from a comma separated list of class=count pairs,
+// e.g. "uppercase=1,digits=2"
+func parseCharsetMinCounts(cons *CharsetConstraints, value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("%s is not of the form class=count", pair)
+		}
+
+		count, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		switch parts[0] {
+		case charsetClassLowercase:
+			cons.MinLowercase = count
+		case charsetClassUppercase:
+			cons.MinUppercase = count
+		case charsetClassDigits:
+			cons.MinDigits = count
+		case charsetClassSymbols:
+			cons.MinSymbols = count
+		default:
+			return fmt.Errorf("%s is not a valid character class, must be one of %s, %s, %s, %s", parts[0],
+				charsetClassLowercase, charsetClassUppercase, charsetClassDigits, charsetClassSymbols)
+		}
+	}
+
+	return nil
+}
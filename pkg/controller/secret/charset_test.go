@@ -0,0 +1,90 @@
+package secret_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+// countCharacters returns the number of characters of value for which is returns true
+func countCharacters(value string, is func(rune) bool) int {
+	count := 0
+	for _, c := range value {
+		if is(c) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestGenerateCharsetStringSatisfiesMinimumCounts(t *testing.T) {
+	cons := &secret.CharsetConstraints{
+		Exclude:          "/+",
+		ExcludeAmbiguous: true,
+		MinUppercase:     2,
+		MinDigits:        3,
+		MinSymbols:       1,
+	}
+
+	for i := 0; i < 100; i++ {
+		value, err := secret.GenerateCharsetString(8, cons)
+		require.NoError(t, err)
+		require.Len(t, value, 8)
+
+		s := string(value)
+		require.GreaterOrEqual(t, countCharacters(s, unicode.IsUpper), 2)
+		require.GreaterOrEqual(t, countCharacters(s, unicode.IsDigit), 3)
+		require.GreaterOrEqual(t, countCharacters(s, func(c rune) bool {
+			return strings.ContainsRune(secret.CharsetSymbols, c)
+		}), 1)
+		require.False(t, strings.ContainsAny(s, "/+"+secret.AmbiguousCharacters))
+	}
+}
+
+func TestGenerateCharsetStringUsesAlphabet(t *testing.T) {
+	value, err := secret.GenerateCharsetString(64, &secret.CharsetConstraints{Alphabet: "abc"})
+	require.NoError(t, err)
+	require.Len(t, value, 64)
+	require.Empty(t, strings.Trim(string(value), "abc"))
+}
+
+func TestCharsetConstraintsValidate(t *testing.T) {
+	invalid := map[string]*secret.CharsetConstraints{
+		"empty alphabet":          {Alphabet: "ab", Exclude: "ab"},
+		"missing class":           {Alphabet: "abc", MinDigits: 1},
+		"negative count":          {MinSymbols: -1},
+		"counts exceeding length": {MinLowercase: 4, MinUppercase: 4, MinDigits: 4},
+	}
+
+	for name, cons := range invalid {
+		require.Error(t, cons.Validate(10), name)
+	}
+}
+
+func TestGenerateCharsetSecretFromAnnotations(t *testing.T) {
+	in := newStringTestSecret("password", map[string]string{
+		secret.AnnotationSecretEncoding:   secret.EncodingCharset,
+		secret.AnnotationSecretLength:     "16",
+		secret.AnnotationCharset:          secret.CharsetLowercase + secret.CharsetDigits,
+		secret.AnnotationCharsetMinCounts: "digits=4",
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+
+	password := string(out.Data["password"])
+	require.Len(t, password, 16)
+	require.GreaterOrEqual(t, countCharacters(password, unicode.IsDigit), 4)
+	require.Empty(t, strings.Trim(password, secret.CharsetLowercase+secret.CharsetDigits))
+}
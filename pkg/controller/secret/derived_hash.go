@@ -0,0 +1,302 @@
+package secret
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/GehirnInc/crypt/common"
+	"github.com/GehirnInc/crypt/sha256_crypt"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// DerivedHash is a hash of a generated value that is stored in a derived key next to the value, so the value can be
+// set up, e.g. as password of a database user, without knowing it in cleartext
+type DerivedHash string
+
+const (
+	// DerivedHashSCRAMSHA256 is a SCRAM-SHA-256 verifier as stored by PostgreSQL
+	DerivedHashSCRAMSHA256 DerivedHash = "scram-sha-256"
+	// DerivedHashMySQLCachingSHA2 is a hash of MySQL's caching_sha2_password authentication plugin
+	DerivedHashMySQLCachingSHA2 DerivedHash = "mysql-caching-sha2"
+	// DerivedHashMySQLNative is a hash of MySQL's mysql_native_password authentication plugin
+	DerivedHashMySQLNative DerivedHash = "mysql-native"
+	// DerivedHashLDAPSSHA is a salted SHA-1 hash in the {SSHA} scheme of LDAP's userPassword attribute
+	DerivedHashLDAPSSHA DerivedHash = "ldap-ssha"
+)
+
+// derivedHashes are all supported derived hashes
+var derivedHashes = []DerivedHash{
+	DerivedHashSCRAMSHA256,
+	DerivedHashMySQLCachingSHA2,
+	DerivedHashMySQLNative,
+	DerivedHashLDAPSSHA,
+}
+
+// parameters of SCRAM-SHA-256 verifiers, matching the defaults of PostgreSQL
+const (
+	scramIterations = 4096
+	scramSaltLen    = 16
+	scramKeyLen     = sha256.Size
+)
+
+// parameters of caching_sha2_password hashes, matching the defaults of MySQL
+const (
+	mysqlCachingSHA2Rounds  = 5000
+	mysqlCachingSHA2SaltLen = 20
+	mysqlCachingSHA2Prefix  = "$A$"
+	mysqlCachingSHA2Salts   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+const ldapSSHASaltLen = 8
+
+// prefixes identifying the derived hashes
+const (
+	scramPrefix    = "SCRAM-SHA-256$"
+	ldapSSHAPrefix = "{SSHA}"
+)
+
+func (dh DerivedHash) Validate() error {
+	for _, hash := range derivedHashes {
+		if dh == hash {
+			return nil
+		}
+	}
+
+	names := make([]string, len(derivedHashes))
+	for i, hash := range derivedHashes {
+		names[i] = string(hash)
+	}
+	return fmt.Errorf("%s is not a valid hash, must be one of %s", dh, strings.Join(names, ", "))
+}
+
+// DerivedHashField returns the key the hash dh of the value of key is stored in
+func DerivedHashField(key string, dh DerivedHash) string {
+	return key + "." + string(dh)
+}
+
+// DerivedHashFields returns the keys the hashes of the values of keys are stored in
+func DerivedHashFields(keys []string, hashes []DerivedHash) []string {
+	fields := make([]string, 0, len(keys)*len(hashes))
+	for _, key := range keys {
+		for _, dh := range hashes {
+			fields = append(fields, DerivedHashField(key, dh))
+		}
+	}
+
+	return fields
+}
+
+// ParseDerivedHashes parses a comma separated list of derived hashes
+func ParseDerivedHashes(value string) ([]DerivedHash, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	names := strings.Split(value, ",")
+	hashes := make([]DerivedHash, 0, len(names))
+	for _, name := range names {
+		hashes = append(hashes, DerivedHash(strings.TrimSpace(name)))
+	}
+
+	return hashes, ValidateDerivedHashes(hashes)
+}
+
+// ValidateDerivedHashes checks whether hashes are supported and unique
+func ValidateDerivedHashes(hashes []DerivedHash) error {
+	seen := make(map[DerivedHash]bool, len(hashes))
+	for _, dh := range hashes {
+		if err := dh.Validate(); err != nil {
+			return err
+		}
+		if seen[dh] {
+			return fmt.Errorf("duplicate hash %s", dh)
+		}
+		seen[dh] = true
+	}
+
+	return nil
+}
+
+// SetDerivedHashes stores the hashes of the value of key in data in their derived keys. Existing hashes are only
+// replaced if they do not match the value anymore, e.g. after regeneration, so salted hashes do not change on
+// every reconciliation. Derived keys of hashes that are not requested are removed.
+func SetDerivedHashes(data map[string][]byte, key string, hashes []DerivedHash) error {
+	value := data[key]
+	for _, dh := range derivedHashes {
+		field := DerivedHashField(key, dh)
+		if !containsDerivedHash(hashes, dh) || len(value) == 0 {
+			delete(data, field)
+			continue
+		}
+
+		if dh.verify(string(data[field]), value) {
+			continue
+		}
+
+		hash, err := dh.generate(value)
+		if err != nil {
+			return err
+		}
+		data[field] = []byte(hash)
+	}
+
+	return nil
+}
+
+// GetDerivedHashesFromAnnotations returns the derived hashes configured by annotations
+func GetDerivedHashesFromAnnotations(annotations map[string]string) ([]DerivedHash, error) {
+	return ParseDerivedHashes(annotations[AnnotationSecretHashes])
+}
+
+func containsDerivedHash(hashes []DerivedHash, dh DerivedHash) bool {
+	for _, hash := range hashes {
+		if hash == dh {
+			return true
+		}
+	}
+	return false
+}
+
+// generate computes the hash dh of password with a random salt
+func (dh DerivedHash) generate(password []byte) (string, error) {
+	switch dh {
+	case DerivedHashSCRAMSHA256:
+		salt := make([]byte, scramSaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		return scramSHA256(password, salt, scramIterations), nil
+	case DerivedHashMySQLCachingSHA2:
+		salt := make([]byte, mysqlCachingSHA2SaltLen)
+		for i := range salt {
+			index, err := randomInt(len(mysqlCachingSHA2Salts))
+			if err != nil {
+				return "", err
+			}
+			salt[i] = mysqlCachingSHA2Salts[index]
+		}
+		return mysqlCachingSHA2(password, salt, mysqlCachingSHA2Rounds)
+	case DerivedHashMySQLNative:
+		return mysqlNative(password), nil
+	case DerivedHashLDAPSSHA:
+		salt := make([]byte, ldapSSHASaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		return ldapSSHA(password, salt), nil
+	}
+
+	return "", dh.Validate()
+}
+
+// verify returns whether hash is the hash dh of password
+func (dh DerivedHash) verify(hash string, password []byte) bool {
+	var computed string
+	switch dh {
+	case DerivedHashSCRAMSHA256:
+		// SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>
+		params, _, _ := strings.Cut(strings.TrimPrefix(hash, scramPrefix), "$")
+		encodedIterations, encodedSalt, _ := strings.Cut(params, ":")
+		// hashes are only verified with the iterations they are generated with, so manipulated hashes can not
+		// trigger expensive computations
+		if encodedIterations != strconv.Itoa(scramIterations) {
+			return false
+		}
+		salt, err := base64.StdEncoding.DecodeString(encodedSalt)
+		if err != nil {
+			return false
+		}
+		computed = scramSHA256(password, salt, scramIterations)
+	case DerivedHashMySQLCachingSHA2:
+		// $A$<rounds / 1000 as 3 hex digits>$<salt><digest>
+		if len(hash) != len(mysqlCachingSHA2Prefix)+4+mysqlCachingSHA2SaltLen+43 || !strings.HasPrefix(hash, mysqlCachingSHA2Prefix) {
+			return false
+		}
+		// like SCRAM-SHA-256 verifiers, hashes are only verified with the rounds they are generated with
+		if hash[3:6] != fmt.Sprintf("%03X", mysqlCachingSHA2Rounds/1000) {
+			return false
+		}
+		var err error
+		salt := hash[7 : 7+mysqlCachingSHA2SaltLen]
+		if computed, err = mysqlCachingSHA2(password, []byte(salt), mysqlCachingSHA2Rounds); err != nil {
+			return false
+		}
+	case DerivedHashMySQLNative:
+		computed = mysqlNative(password)
+	case DerivedHashLDAPSSHA:
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(hash, ldapSSHAPrefix))
+		if err != nil || !strings.HasPrefix(hash, ldapSSHAPrefix) || len(decoded) <= sha1.Size {
+			return false
+		}
+		computed = ldapSSHA(password, decoded[sha1.Size:])
+	default:
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(hash)) == 1
+}
+
+// scramSHA256 computes a SCRAM-SHA-256 verifier in the format of PostgreSQL, see RFC 5802 and RFC 7677
+func scramSHA256(password, salt []byte, iterations int) string {
+	salted := pbkdf2.Key(password, salt, iterations, scramKeyLen, sha256.New)
+
+	clientKey := hmacSHA256(salted, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+	serverKey := hmacSHA256(salted, []byte("Server Key"))
+
+	return fmt.Sprintf("%s%d:%s$%s:%s", scramPrefix, iterations, base64.StdEncoding.EncodeToString(salt),
+		base64.StdEncoding.EncodeToString(storedKey[:]), base64.StdEncoding.EncodeToString(serverKey))
+}
+
+func hmacSHA256(key, message []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// mysqlCachingSHA2 computes a caching_sha2_password hash, which is a SHA256-crypt digest with a 20 character salt
+// serialized as $A$<rounds / 1000>$<salt><digest>
+func mysqlCachingSHA2(password, salt []byte, rounds int) (string, error) {
+	crypter := sha256_crypt.New()
+	crypter.SetSalt(common.Salt{
+		MagicPrefix:   []byte(sha256_crypt.MagicPrefix),
+		SaltLenMin:    mysqlCachingSHA2SaltLen,
+		SaltLenMax:    mysqlCachingSHA2SaltLen,
+		RoundsMin:     sha256_crypt.RoundsMin,
+		RoundsMax:     sha256_crypt.RoundsMax,
+		RoundsDefault: mysqlCachingSHA2Rounds,
+	})
+
+	hash, err := crypter.Generate(password, []byte(fmt.Sprintf("%srounds=%d$%s", sha256_crypt.MagicPrefix, rounds, salt)))
+	if err != nil {
+		return "", err
+	}
+	digest := hash[strings.LastIndex(hash, "$")+1:]
+
+	return fmt.Sprintf("%s%03X$%s%s", mysqlCachingSHA2Prefix, rounds/1000, salt, digest), nil
+}
+
+// mysqlNative computes a mysql_native_password hash, which is the hex encoded SHA1(SHA1(password)) prefixed with *
+func mysqlNative(password []byte) string {
+	first := sha1.Sum(password)
+	second := sha1.Sum(first[:])
+
+	return "*" + strings.ToUpper(hex.EncodeToString(second[:]))
+}
+
+// ldapSSHA computes a {SSHA} hash, which is the base64 encoded SHA1(password + salt) followed by the salt
+func ldapSSHA(password, salt []byte) string {
+	h := sha1.New()
+	h.Write(password)
+	h.Write(salt)
+
+	return ldapSSHAPrefix + base64.StdEncoding.EncodeToString(append(h.Sum(nil), salt...))
+}
@@ -0,0 +1,124 @@
+package secret_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/stringsecret"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+var allDerivedHashes = []secret.DerivedHash{
+	secret.DerivedHashSCRAMSHA256,
+	secret.DerivedHashMySQLCachingSHA2,
+	secret.DerivedHashMySQLNative,
+	secret.DerivedHashLDAPSSHA,
+}
+
+func TestSetDerivedHashes(t *testing.T) {
+	data := map[string][]byte{"password": []byte("secret123")}
+	require.NoError(t, secret.SetDerivedHashes(data, "password", allDerivedHashes))
+
+	require.Equal(t, "*8C9B6F6F6387801FD5F1E6211872FDDB614099EC", string(data["password.mysql-native"]))
+	require.Regexp(t, `^SCRAM-SHA-256\$4096:[A-Za-z0-9+/=]{24}\$[A-Za-z0-9+/=]{44}:[A-Za-z0-9+/=]{44}$`, string(data["password.scram-sha-256"]))
+	require.Regexp(t, `^\$A\$005\$[A-Za-z0-9]{20}[./A-Za-z0-9]{43}$`, string(data["password.mysql-caching-sha2"]))
+
+	ssha, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(string(data["password.ldap-ssha"]), "{SSHA}"))
+	require.NoError(t, err)
+	digest := sha1.Sum(append([]byte("secret123"), ssha[sha1.Size:]...))
+	require.Equal(t, digest[:], ssha[:sha1.Size])
+
+	// salted hashes are kept as long as they match the value
+	hashes := make(map[string]string)
+	for key, value := range data {
+		hashes[key] = string(value)
+	}
+	require.NoError(t, secret.SetDerivedHashes(data, "password", allDerivedHashes))
+	for key, value := range data {
+		require.Equal(t, hashes[key], string(value), key)
+	}
+
+	// hashes follow changes of the value and are removed if they are not requested anymore
+	data["password"] = []byte("changed")
+	require.NoError(t, secret.SetDerivedHashes(data, "password", allDerivedHashes[:1]))
+	require.Len(t, data, 2)
+	require.NotEqual(t, hashes["password.scram-sha-256"], string(data["password.scram-sha-256"]))
+}
+
+func TestParseDerivedHashes(t *testing.T) {
+	hashes, err := secret.ParseDerivedHashes("scram-sha-256, ldap-ssha")
+	require.NoError(t, err)
+	require.Equal(t, []secret.DerivedHash{secret.DerivedHashSCRAMSHA256, secret.DerivedHashLDAPSSHA}, hashes)
+
+	_, err = secret.ParseDerivedHashes("md5")
+	require.Error(t, err)
+	_, err = secret.ParseDerivedHashes("mysql-native,mysql-native")
+	require.Error(t, err)
+}
+
+func TestGenerateDerivedHashesFromAnnotations(t *testing.T) {
+	in := newStringTestSecret("password", map[string]string{
+		secret.AnnotationSecretHashes: "mysql-native",
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.NotEmpty(t, out.Data["password"])
+	require.NotEmpty(t, out.Data["password.mysql-native"])
+	hash := string(out.Data["password.mysql-native"])
+
+	// regeneration updates the hash
+	out.Annotations[secret.AnnotationSecretRegenerate] = "yes"
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), out))
+
+	doReconcile(t, out, false)
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.NotEqual(t, hash, string(out.Data["password.mysql-native"]))
+}
+
+func TestControllerGenerateDerivedHashes(t *testing.T) {
+	in := newStringSecretTestCR(v1alpha1.StringSecretSpec{
+		Fields: []v1alpha1.Field{{FieldName: "password", Hashes: []string{"scram-sha-256", "ldap-ssha"}}},
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileStringSecretController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.True(t, strings.HasPrefix(string(out.Data["password.scram-sha-256"]), "SCRAM-SHA-256$"))
+	require.True(t, strings.HasPrefix(string(out.Data["password.ldap-ssha"]), "{SSHA}"))
+
+	cr := getStringSecretTestCR(t, in)
+	require.ElementsMatch(t, []string{"password", "password.scram-sha-256", "password.ldap-ssha"}, cr.Status.GeneratedFields)
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), cr))
+}
+
+func TestStringSecretValidateSpecHashes(t *testing.T) {
+	in := newStringSecretTestCR(v1alpha1.StringSecretSpec{
+		Fields: []v1alpha1.Field{{FieldName: "password", Hashes: []string{"md5"}}},
+	}, "")
+	require.Error(t, stringsecret.ValidateSpec(in))
+
+	in.Spec.Fields = []v1alpha1.Field{
+		{FieldName: "password", Hashes: []string{"mysql-native"}},
+		{FieldName: "password.mysql-native"},
+	}
+	require.Error(t, stringsecret.ValidateSpec(in))
+
+	in.Spec.Fields = in.Spec.Fields[:1]
+	require.NoError(t, stringsecret.ValidateSpec(in))
+}
@@ -0,0 +1,56 @@
+package secret
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the events emitted for generated Secrets
+const (
+	EventReasonGenerated         = "Generated"
+	EventReasonRegenerated       = "Regenerated"
+	EventReasonInvalidAnnotation = "InvalidAnnotation"
+	EventReasonGenerationFailed  = "GenerationFailed"
+)
+
+// RecordGenerationEvents emits events on object naming the fields whose values in values were newly generated or
+// regenerated compared to existing. Only the names of the fields are included, never their values.
+func RecordGenerationEvents(recorder record.EventRecorder, object runtime.Object, fields []string, existing, values map[string][]byte) {
+	var generated, regenerated []string
+	for _, field := range fields {
+		if len(values[field]) == 0 || bytes.Equal(existing[field], values[field]) {
+			continue
+		}
+
+		if len(existing[field]) == 0 {
+			generated = append(generated, field)
+		} else {
+			regenerated = append(regenerated, field)
+		}
+	}
+
+	if len(generated) > 0 {
+		recorder.Eventf(object, corev1.EventTypeNormal, EventReasonGenerated, "generated fields %s", strings.Join(generated, ", "))
+	}
+	if len(regenerated) > 0 {
+		recorder.Eventf(object, corev1.EventTypeNormal, EventReasonRegenerated, "regenerated fields %s", strings.Join(regenerated, ", "))
+	}
+}
+
+// dataKeys returns the sorted keys of data, without the keys holding previous values
+func dataKeys(data map[string][]byte) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		if !strings.HasSuffix(key, SecretFieldPreviousSuffix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
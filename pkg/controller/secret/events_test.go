@@ -0,0 +1,49 @@
+package secret_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+func TestRecordGenerationEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+
+	existing := map[string][]byte{
+		"password": []byte("old-password"),
+		"username": []byte("admin"),
+	}
+	values := map[string][]byte{
+		"password": []byte("new-password"),
+		"token":    []byte("new-token"),
+		"username": []byte("admin"),
+	}
+
+	secret.RecordGenerationEvents(recorder, &corev1.Secret{}, []string{"password", "token", "username"}, existing, values)
+
+	require.Len(t, recorder.Events, 2)
+	generated := <-recorder.Events
+	regenerated := <-recorder.Events
+
+	require.Equal(t, "Normal Generated generated fields token", generated)
+	require.Equal(t, "Normal Regenerated regenerated fields password", regenerated)
+
+	// values must never be part of events
+	require.NotContains(t, generated+regenerated, "new-")
+}
+
+func TestRecordGenerationEventsWithoutChanges(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+
+	values := map[string][]byte{
+		"password": []byte("password"),
+	}
+
+	secret.RecordGenerationEvents(recorder, &corev1.Secret{}, []string{"password"}, values, values)
+
+	require.Empty(t, recorder.Events)
+}
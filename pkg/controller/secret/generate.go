@@ -0,0 +1,147 @@
+package secret
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// secretType returns the type of the values requested by the annotations of instance. Secrets with an autogenerate
+// annotation but no type default to the string type to keep backwards compatibility. Unknown types are returned
+// as they are, so they are rejected by ValidateAnnotations. False is returned if instance is not annotated for
+// generation.
+func secretType(instance *corev1.Secret) (Type, bool) {
+	sType := Type(instance.Annotations[AnnotationSecretType])
+	if sType == "" {
+		if _, ok := instance.Annotations[AnnotationSecretAutoGenerate]; !ok {
+			return "", false
+		}
+
+		return TypeString, true
+	}
+
+	return sType, true
+}
+
+// newGenerator returns the Generator for values of type sType, or nil if there is none
+func newGenerator(sType Type, logger logr.Logger, c client.Client) Generator {
+	switch sType {
+	case TypeSSHKeypair:
+		return SSHKeypairGenerator{
+			log:    logger.WithValues("type", TypeSSHKeypair),
+			client: c,
+		}
+	case TypeString:
+		return StringGenerator{
+			log: logger.WithValues("type", TypeString),
+		}
+	case TypeBasicAuth:
+		return BasicAuthGenerator{
+			log: logger.WithValues("type", TypeBasicAuth),
+		}
+	case TypeTLS:
+		return TLSGenerator{
+			log: logger.WithValues("type", TypeTLS),
+		}
+	case TypeDockerRegistry:
+		return DockerRegistryGenerator{
+			log: logger.WithValues("type", TypeDockerRegistry),
+		}
+	case TypeWireGuard:
+		return WireGuardKeypairGenerator{
+			log: logger.WithValues("type", TypeWireGuard),
+		}
+	case TypeJWT:
+		return JWTGenerator{
+			log: logger.WithValues("type", TypeJWT),
+		}
+	case TypeTOTP:
+		return TOTPGenerator{
+			log: logger.WithValues("type", TypeTOTP),
+		}
+	case TypePGPKeypair:
+		return PGPKeypairGenerator{
+			log:    logger.WithValues("type", TypePGPKeypair),
+			client: c,
+		}
+	case TypeAgeKeypair:
+		return AgeKeypairGenerator{
+			log: logger.WithValues("type", TypeAgeKeypair),
+		}
+	}
+
+	return nil
+}
+
+// generatedKeys returns the keys generated for a Secret of type sType configured by annotations
+func generatedKeys(sType Type, annotations map[string]string) []string {
+	switch sType {
+	case TypeString:
+		keys := strings.Split(annotations[AnnotationSecretAutoGenerate], ",")
+		// invalid hashes are rejected by the validation of their annotation
+		hashes, _ := GetDerivedHashesFromAnnotations(annotations)
+		return append(keys, DerivedHashFields(keys, hashes)...)
+	case TypeSSHKeypair:
+		return []string{SecretFieldPrivateKey, SecretFieldPublicKey}
+	case TypeBasicAuth:
+		return []string{FieldBasicAuthIngress, FieldBasicAuthUsername, FieldBasicAuthPassword}
+	case TypeTLS:
+		return []string{SecretFieldTLSCertificate, SecretFieldTLSPrivateKey, SecretFieldTLSCA}
+	case TypeDockerRegistry:
+		return []string{FieldDockerConfigJSON, FieldDockerRegistryHtpasswd, FieldBasicAuthUsername, FieldBasicAuthPassword}
+	case TypeWireGuard:
+		// an invalid preshared key annotation is rejected by its validation
+		presharedKey, _ := strconv.ParseBool(annotations[AnnotationWireGuardPresharedKey])
+		return WireGuardKeypairFields(&WireGuardKeypairConstraints{PresharedKey: presharedKey})
+	case TypeJWT:
+		return []string{FieldJWTPrivateKey, FieldJWTPublicKey, FieldJWTKeyID, FieldJWKS}
+	case TypeTOTP:
+		qrCode, _ := strconv.ParseBool(annotations[AnnotationTOTPQRCode])
+		return TOTPFields(&TOTPConstraints{QRCode: qrCode})
+	case TypePGPKeypair:
+		return []string{FieldPGPPrivateKey, FieldPGPPublicKey, FieldPGPFingerprint}
+	case TypeAgeKeypair:
+		return []string{FieldAgeIdentity, FieldAgeRecipient}
+	}
+
+	return nil
+}
+
+// GenerateData fills instance with the values requested by its annotations, using the same generators as the
+// Secret controller. False is returned if instance is not annotated for generation.
+func GenerateData(logger logr.Logger, c client.Client, instance *corev1.Secret) (bool, error) {
+	sType, ok := secretType(instance)
+	if !ok {
+		return false, nil
+	}
+
+	// annotations are validated before the type is defaulted, so an unknown type is rejected
+	if err := ValidateAnnotations(instance.Annotations); err != nil {
+		return false, err
+	}
+	instance.Annotations[AnnotationSecretType] = string(sType)
+
+	generator := newGenerator(sType, logger, c)
+	if generator == nil {
+		return false, fmt.Errorf("no generator for secret type %s", sType)
+	}
+
+	if instance.Data == nil {
+		instance.Data = make(map[string][]byte)
+	}
+
+	if _, err := generator.generateData(instance); err != nil {
+		return false, err
+	}
+	if err := RenderTemplates(GetTemplatesFromAnnotations(instance.Annotations), instance.Data); err != nil {
+		return false, err
+	}
+	instance.Annotations[AnnotationSecretAutoGeneratedAt] = time.Now().Format(time.RFC3339)
+
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package secret_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+func TestGenerateData(t *testing.T) {
+	in := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSecretName(),
+			Namespace: "default",
+			Annotations: map[string]string{
+				secret.AnnotationSecretAutoGenerate: "password",
+			},
+		},
+	}
+
+	generated, err := secret.GenerateData(logf.Log, mgr.GetClient(), in)
+	require.NoError(t, err)
+	require.True(t, generated)
+	require.Len(t, in.Data["password"], desiredLength(in))
+	require.Equal(t, string(secret.TypeString), in.Annotations[secret.AnnotationSecretType])
+	require.NotEmpty(t, in.Annotations[secret.AnnotationSecretAutoGeneratedAt])
+
+	// existing values are kept
+	password := string(in.Data["password"])
+	generated, err = secret.GenerateData(logf.Log, mgr.GetClient(), in)
+	require.NoError(t, err)
+	require.True(t, generated)
+	require.Equal(t, password, string(in.Data["password"]))
+}
+
+func TestGenerateDataIgnoresSecretsWithoutAnnotations(t *testing.T) {
+	in := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        getSecretName(),
+			Namespace:   "default",
+			Annotations: map[string]string{"unrelated": "value"},
+		},
+	}
+
+	generated, err := secret.GenerateData(logf.Log, mgr.GetClient(), in)
+	require.NoError(t, err)
+	require.False(t, generated)
+	require.Empty(t, in.Data)
+}
+
+func TestGenerateDataRejectsInvalidAnnotations(t *testing.T) {
+	in := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSecretName(),
+			Namespace: "default",
+			Annotations: map[string]string{
+				secret.AnnotationSecretAutoGenerate: "password",
+				secret.AnnotationSecretType:         "password",
+			},
+		},
+	}
+
+	generated, err := secret.GenerateData(logf.Log, mgr.GetClient(), in)
+	require.Error(t, err)
+	require.False(t, generated)
+	require.Empty(t, in.Data)
+
+	// a missing passphrase secret fails the generation
+	in.Annotations = map[string]string{
+		secret.AnnotationSecretType:       string(secret.TypeSSHKeypair),
+		secret.AnnotationPassphraseSecret: "missing",
+	}
+	generated, err = secret.GenerateData(logf.Log, mgr.GetClient(), in)
+	require.Error(t, err)
+	require.False(t, generated)
+}
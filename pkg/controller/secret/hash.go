@@ -0,0 +1,182 @@
+package secret
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/GehirnInc/crypt/apr1_crypt"
+	"github.com/GehirnInc/crypt/sha512_crypt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type HashAlgorithm string
+
+const (
+	HashAlgorithmBcrypt   HashAlgorithm = "bcrypt"
+	HashAlgorithmSHA512   HashAlgorithm = "sha512"
+	HashAlgorithmAPR1     HashAlgorithm = "apr1"
+	HashAlgorithmArgon2id HashAlgorithm = "argon2id"
+)
+
+// DefaultHashAlgorithm is the algorithm passwords are hashed with if no algorithm is configured
+const DefaultHashAlgorithm = HashAlgorithmBcrypt
+
+// parameters of argon2id hashes, following the recommendations of OWASP
+const (
+	argon2idTime    = 2
+	argon2idMemory  = 19 * 1024
+	argon2idThreads = 1
+	argon2idKeyLen  = 32
+	argon2idSaltLen = 16
+)
+
+// prefixes identifying the algorithm of hashes
+const (
+	hashPrefixSHA512   = sha512_crypt.MagicPrefix
+	hashPrefixAPR1     = apr1_crypt.MagicPrefix
+	hashPrefixArgon2id = "$argon2id$"
+)
+
+var hashPrefixesBcrypt = []string{"$2a$", "$2b$", "$2y$"}
+
+func (ha HashAlgorithm) Validate() error {
+	switch ha {
+	case HashAlgorithmBcrypt,
+		HashAlgorithmSHA512,
+		HashAlgorithmAPR1,
+		HashAlgorithmArgon2id:
+		return nil
+	}
+	return fmt.Errorf("%s is not a valid hash algorithm", ha)
+}
+
+// ValidateHashCost checks whether passwords can be hashed with algorithm and cost. The cost is only supported
+// by bcrypt, 0 selects the default cost.
+func ValidateHashCost(algorithm HashAlgorithm, cost int) error {
+	if cost == 0 {
+		return nil
+	}
+	if algorithm != HashAlgorithmBcrypt {
+		return fmt.Errorf("cost is not supported by hash algorithm %s", algorithm)
+	}
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return nil
+}
+
+// HashPassword hashes password with algorithm. The cost is only taken into account for bcrypt, 0 selects
+// the default cost.
+func HashPassword(password []byte, algorithm HashAlgorithm, cost int) (string, error) {
+	switch algorithm {
+	case HashAlgorithmBcrypt:
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+		hash, err := bcrypt.GenerateFromPassword(password, cost)
+		return string(hash), err
+	case HashAlgorithmSHA512:
+		return sha512_crypt.New().Generate(password, nil)
+	case HashAlgorithmAPR1:
+		return apr1_crypt.New().Generate(password, nil)
+	case HashAlgorithmArgon2id:
+		return hashArgon2id(password)
+	}
+
+	return "", fmt.Errorf("%s is not a valid hash algorithm", algorithm)
+}
+
+// hashArgon2id hashes password with argon2id, encoded in the PHC string format
+func hashArgon2id(password []byte) (string, error) {
+	salt := make([]byte, argon2idSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey(password, salt, argon2idTime, argon2idMemory, argon2idThreads, argon2idKeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", hashPrefixArgon2id, argon2.Version, argon2idMemory, argon2idTime,
+		argon2idThreads, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// HashMatches returns whether hash was computed with algorithm and, for bcrypt, with cost
+func HashMatches(hash string, algorithm HashAlgorithm, cost int) bool {
+	switch algorithm {
+	case HashAlgorithmBcrypt:
+		if !hasAnyPrefix(hash, hashPrefixesBcrypt) {
+			return false
+		}
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+		hashCost, err := bcrypt.Cost([]byte(hash))
+		return err == nil && hashCost == cost
+	case HashAlgorithmSHA512:
+		return strings.HasPrefix(hash, hashPrefixSHA512)
+	case HashAlgorithmAPR1:
+		return strings.HasPrefix(hash, hashPrefixAPR1)
+	case HashAlgorithmArgon2id:
+		return strings.HasPrefix(hash, hashPrefixArgon2id)
+	}
+
+	return false
+}
+
+// VerifyPassword returns whether hash is a hash of password
+func VerifyPassword(hash string, password []byte) bool {
+	switch {
+	case hasAnyPrefix(hash, hashPrefixesBcrypt):
+		return bcrypt.CompareHashAndPassword([]byte(hash), password) == nil
+	case strings.HasPrefix(hash, hashPrefixSHA512):
+		return sha512_crypt.New().Verify(hash, password) == nil
+	case strings.HasPrefix(hash, hashPrefixAPR1):
+		return apr1_crypt.New().Verify(hash, password) == nil
+	case strings.HasPrefix(hash, hashPrefixArgon2id):
+		return verifyArgon2id(hash, password)
+	}
+
+	return false
+}
+
+// verifyArgon2id returns whether the argon2id hash in the PHC string format is a hash of password
+func verifyArgon2id(hash string, password []byte) bool {
+	var version, memory, time int
+	var threads uint8
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false
+	}
+
+	computed := argon2.IDKey(password, salt, uint32(time), uint32(memory), threads, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(computed, key) == 1
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package secret_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+// authHash returns the password hash of the auth field of data
+func authHash(data map[string][]byte) string {
+	return strings.SplitN(string(data[secret.FieldBasicAuthIngress]), ":", 2)[1]
+}
+
+func TestHashPassword(t *testing.T) {
+	algorithms := []secret.HashAlgorithm{
+		secret.HashAlgorithmBcrypt,
+		secret.HashAlgorithmSHA512,
+		secret.HashAlgorithmAPR1,
+		secret.HashAlgorithmArgon2id,
+	}
+
+	for _, algorithm := range algorithms {
+		hash, err := secret.HashPassword([]byte("password"), algorithm, 0)
+		require.NoError(t, err, algorithm)
+		require.True(t, secret.VerifyPassword(hash, []byte("password")), algorithm)
+		require.False(t, secret.VerifyPassword(hash, []byte("other")), algorithm)
+
+		for _, other := range algorithms {
+			require.Equal(t, algorithm == other, secret.HashMatches(hash, other, 0), "%s, %s", algorithm, other)
+		}
+	}
+}
+
+func TestHashPasswordBcryptCost(t *testing.T) {
+	hash, err := secret.HashPassword([]byte("password"), secret.HashAlgorithmBcrypt, 5)
+	require.NoError(t, err)
+	require.True(t, secret.HashMatches(hash, secret.HashAlgorithmBcrypt, 5))
+	require.False(t, secret.HashMatches(hash, secret.HashAlgorithmBcrypt, 0))
+
+	require.Error(t, secret.ValidateHashCost(secret.HashAlgorithmBcrypt, 2))
+	require.Error(t, secret.ValidateHashCost(secret.HashAlgorithmSHA512, 12))
+}
+
+func TestRehashBasicAuthData(t *testing.T) {
+	data := map[string][]byte{}
+	require.NoError(t, secret.GenerateBasicAuthData(logf.Log, &secret.BasicAuthConstraints{Username: "user"}, data))
+	password := string(data[secret.FieldBasicAuthPassword])
+
+	rehashed, err := secret.RehashBasicAuthData(logf.Log, &secret.BasicAuthConstraints{HashAlgorithm: secret.HashAlgorithmBcrypt}, data)
+	require.NoError(t, err)
+	require.False(t, rehashed)
+
+	rehashed, err = secret.RehashBasicAuthData(logf.Log, &secret.BasicAuthConstraints{HashAlgorithm: secret.HashAlgorithmSHA512}, data)
+	require.NoError(t, err)
+	require.True(t, rehashed)
+
+	require.Equal(t, password, string(data[secret.FieldBasicAuthPassword]))
+	require.True(t, strings.HasPrefix(string(data[secret.FieldBasicAuthIngress]), "user:"))
+	require.True(t, secret.HashMatches(authHash(data), secret.HashAlgorithmSHA512, 0))
+	require.True(t, secret.VerifyPassword(authHash(data), []byte(password)))
+}
+
+func TestGenerateBasicAuthWithHashAlgorithm(t *testing.T) {
+	in := newBasicAuthTestSecret(map[string]string{
+		secret.AnnotationBasicAuthAlgorithm: string(secret.HashAlgorithmAPR1),
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.True(t, secret.HashMatches(authHash(out.Data), secret.HashAlgorithmAPR1, 0))
+	require.True(t, secret.VerifyPassword(authHash(out.Data), out.Data[secret.FieldBasicAuthPassword]))
+}
+
+func TestControllerRehashBasicAuthOnAlgorithmChange(t *testing.T) {
+	in := newBasicAuthTestCR(v1alpha1.BasicAuthSpec{Username: testUsername}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileBasicAuthController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.True(t, secret.HashMatches(authHash(out.Data), secret.HashAlgorithmBcrypt, 0))
+	password := string(out.Data[secret.FieldBasicAuthPassword])
+
+	cr := &v1alpha1.BasicAuth{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, cr))
+	cr.Spec.HashAlgorithm = string(secret.HashAlgorithmArgon2id)
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), cr))
+
+	doReconcileBasicAuthController(t, cr, false)
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.Equal(t, password, string(out.Data[secret.FieldBasicAuthPassword]))
+	require.True(t, secret.HashMatches(authHash(out.Data), secret.HashAlgorithmArgon2id, 0))
+	require.True(t, secret.VerifyPassword(authHash(out.Data), []byte(password)))
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), cr))
+}
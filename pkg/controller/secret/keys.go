@@ -0,0 +1,189 @@
+package secret
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+type KeyAlgorithm string
+
+const (
+	KeyAlgorithmRSA       KeyAlgorithm = "rsa"
+	KeyAlgorithmEd25519   KeyAlgorithm = "ed25519"
+	KeyAlgorithmECDSAP256 KeyAlgorithm = "ecdsa-p256"
+	KeyAlgorithmECDSAP384 KeyAlgorithm = "ecdsa-p384"
+	KeyAlgorithmECDSAP521 KeyAlgorithm = "ecdsa-p521"
+)
+
+type PrivateKeyFormat string
+
+const (
+	PrivateKeyFormatPKCS1   PrivateKeyFormat = "pkcs1"
+	PrivateKeyFormatPKCS8   PrivateKeyFormat = "pkcs8"
+	PrivateKeyFormatOpenSSH PrivateKeyFormat = "openssh"
+)
+
+// MinRSAKeyLength is the minimum size of RSA keys in bits for key types whose length is validated
+const MinRSAKeyLength = 2048
+
+func (ka KeyAlgorithm) Validate() error {
+	switch ka {
+	case KeyAlgorithmRSA,
+		KeyAlgorithmEd25519,
+		KeyAlgorithmECDSAP256,
+		KeyAlgorithmECDSAP384,
+		KeyAlgorithmECDSAP521:
+		return nil
+	}
+	return fmt.Errorf("%s is not a valid key algorithm", ka)
+}
+
+func (pf PrivateKeyFormat) Validate() error {
+	switch pf {
+	case PrivateKeyFormatPKCS1,
+		PrivateKeyFormatPKCS8,
+		PrivateKeyFormatOpenSSH:
+		return nil
+	}
+	return fmt.Errorf("%s is not a valid private key format", pf)
+}
+
+// ParseRSAKeyLength parses the size of RSA keys in bits, which defaults to fallback. Lengths in bytes are rejected,
+// as the size of keys is always given in bits, and so are keys shorter than MinRSAKeyLength.
+// GeneratePrivateKey does not check the size itself, as SSH key pairs accept shorter keys for backwards compatibility.
+func ParseRSAKeyLength(fallback int, length string) (int, error) {
+	bits, isByteLength, err := ParseByteLength(fallback, length)
+	if err != nil {
+		return 0, err
+	}
+	if isByteLength {
+		return 0, fmt.Errorf("RSA key length %s must be given in bits", length)
+	}
+	if bits < MinRSAKeyLength {
+		return 0, fmt.Errorf("RSA key length %d is too short, must be at least %d bits", bits, MinRSAKeyLength)
+	}
+
+	return bits, nil
+}
+
+// GeneratePrivateKey generates a new private key using the given algorithm. rsaBits is only
+// taken into account for RSA keys, the key size of all other algorithms is determined by the algorithm itself.
+func GeneratePrivateKey(algorithm KeyAlgorithm, rsaBits int) (crypto.Signer, error) {
+	switch algorithm {
+	case KeyAlgorithmRSA:
+		return rsa.GenerateKey(rand.Reader, rsaBits)
+	case KeyAlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case KeyAlgorithmECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyAlgorithmECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyAlgorithmECDSAP521:
+		return ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	}
+	return nil, algorithm.Validate()
+}
+
+// MarshalPrivateKeyPEM encodes the given private key into a PEM block of the given format. If format is empty,
+// the format traditionally used for the key's algorithm is chosen, i.e. PKCS#1 for RSA, SEC 1 for ECDSA and
+// the OpenSSH format for ed25519 keys. Only keys in the OpenSSH format can be protected by a passphrase.
+func MarshalPrivateKeyPEM(key crypto.Signer, format PrivateKeyFormat, passphrase []byte) (*pem.Block, error) {
+	if len(passphrase) > 0 {
+		if format != "" && format != PrivateKeyFormatOpenSSH {
+			return nil, fmt.Errorf("passphrase protection is not supported for private key format %s", format)
+		}
+		return ssh.MarshalPrivateKeyWithPassphrase(key, "", passphrase)
+	}
+
+	switch format {
+	case "":
+		return marshalTraditionalPrivateKeyPEM(key)
+	case PrivateKeyFormatPKCS1:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("private key format %s is only supported for RSA keys", format)
+		}
+		return &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}, nil
+	case PrivateKeyFormatPKCS8:
+		b, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: "PRIVATE KEY", Bytes: b}, nil
+	case PrivateKeyFormatOpenSSH:
+		return ssh.MarshalPrivateKey(key, "")
+	}
+	return nil, format.Validate()
+}
+
+// marshalTraditionalPrivateKeyPEM encodes the given private key into the PEM format traditionally used for its algorithm
+func marshalTraditionalPrivateKeyPEM(key crypto.Signer) (*pem.Block, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}, nil
+	case *ecdsa.PrivateKey:
+		b, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: b}, nil
+	case ed25519.PrivateKey:
+		return ssh.MarshalPrivateKey(k, "")
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", key)
+}
+
+// ParsePrivateKeyPEM parses a PEM encoded private key. PKCS#1, PKCS#8, SEC 1 and OpenSSH encoded keys are supported.
+func ParsePrivateKeyPEM(pemKey []byte) (crypto.Signer, error) {
+	b, _ := pem.Decode(pemKey)
+	if b == nil {
+		return nil, errors.New("failed to parse private Key PEM block")
+	}
+
+	var key interface{}
+	var err error
+	switch b.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(b.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(b.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(b.Bytes)
+	case "OPENSSH PRIVATE KEY":
+		key, err = ssh.ParseRawPrivateKey(pemKey)
+	default:
+		return nil, fmt.Errorf("unsupported private key PEM block type %s", b.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return signerFromKey(key)
+}
+
+// signerFromKey converts the result of one of the various private key parsers into a crypto.Signer
+func signerFromKey(key interface{}) (crypto.Signer, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	case *ed25519.PrivateKey:
+		// the OpenSSH parser returns ed25519 keys as pointers
+		return *k, nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", key)
+}
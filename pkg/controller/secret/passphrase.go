@@ -0,0 +1,153 @@
+package secret
+
+import (
+	_ "embed" // embeds the wordlist of passphrases
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// EncodingPassphrase is the encoding generating passphrases of words drawn from the EFF large wordlist
+const EncodingPassphrase = "passphrase"
+
+// DefaultPassphraseWords is the number of words of passphrases if no number is configured
+const DefaultPassphraseWords = 6
+
+// DefaultPassphraseSeparator separates the words of passphrases if no separator is configured
+const DefaultPassphraseSeparator = "-"
+
+// maxPassphraseWords limits the number of words of passphrases
+const maxPassphraseWords = 64
+
+// effLargeWordlist is the EFF large wordlist, see https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases.
+// Each line consists of the dice rolls selecting a word and the word itself, separated by a tab.
+//
+//go:embed eff_large_wordlist.txt
+var effLargeWordlist string
+
+// passphraseWords are the words of effLargeWordlist
+var passphraseWords = parseWordlist(effLargeWordlist)
+
+// parseWordlist returns the words of a diceware wordlist
+func parseWordlist(wordlist string) []string {
+	lines := strings.Split(strings.TrimSpace(wordlist), "\n")
+	words := make([]string, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		words = append(words, fields[len(fields)-1])
+	}
+
+	return words
+}
+
+// PassphraseConstraints configures the generation of passphrases
+type PassphraseConstraints struct {
+	// Words is the number of words, defaults to DefaultPassphraseWords
+	Words int
+	// Separator separates the words, defaults to DefaultPassphraseSeparator
+	Separator string
+	// Capitalize capitalizes the first letter of each word
+	Capitalize bool
+	// Digit appends a random digit to a random word
+	Digit bool
+}
+
+// Validate checks whether passphrases can be generated with the given constraints
+func (cons *PassphraseConstraints) Validate() error {
+	if cons.Words < 0 || cons.Words > maxPassphraseWords {
+		return fmt.Errorf("number of words must be between 1 and %d, or 0 for the default of %d", maxPassphraseWords, DefaultPassphraseWords)
+	}
+
+	return nil
+}
+
+// GeneratePassphrase generates a passphrase of words drawn uniformly from the EFF large wordlist using crypto/rand
+func GeneratePassphrase(cons *PassphraseConstraints) ([]byte, error) {
+	if err := cons.Validate(); err != nil {
+		return nil, err
+	}
+
+	count := cons.Words
+	if count == 0 {
+		count = DefaultPassphraseWords
+	}
+
+	separator := cons.Separator
+	if separator == "" {
+		separator = DefaultPassphraseSeparator
+	}
+
+	words := make([]string, count)
+	for i := range words {
+		index, err := randomInt(len(passphraseWords))
+		if err != nil {
+			return nil, err
+		}
+
+		words[i] = passphraseWords[index]
+		if cons.Capitalize {
+			first, size := utf8.DecodeRuneInString(words[i])
+			words[i] = string(unicode.ToUpper(first)) + words[i][size:]
+		}
+	}
+
+	if cons.Digit {
+		index, err := randomInt(count)
+		if err != nil {
+			return nil, err
+		}
+		digit, err := randomInt(10)
+		if err != nil {
+			return nil, err
+		}
+		words[index] += strconv.Itoa(digit)
+	}
+
+	return []byte(strings.Join(words, separator)), nil
+}
+
+// GetPassphraseConstraintsFromAnnotations returns the constraints of passphrases configured by annotations
+func GetPassphraseConstraintsFromAnnotations(annotations map[string]string) (*PassphraseConstraints, error) {
+	cons := &PassphraseConstraints{
+		Separator: annotations[AnnotationPassphraseSeparator],
+	}
+
+	var err error
+	if value, ok := annotations[AnnotationPassphraseWords]; ok {
+		if cons.Words, err = parsePassphraseWords(value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := annotations[AnnotationPassphraseCapitalize]; ok {
+		if cons.Capitalize, err = strconv.ParseBool(value); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	if value, ok := annotations[AnnotationPassphraseDigit]; ok {
+		if cons.Digit, err = strconv.ParseBool(value); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return cons, nil
+}
+
+// parsePassphraseWords parses the number of words of passphrases
+func parsePassphraseWords(value string) (int, error) {
+	words, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	// unlike in PassphraseConstraints, 0 does not select the default
+	if words < 1 || words > maxPassphraseWords {
+		return 0, fmt.Errorf("number of words must be between 1 and %d", maxPassphraseWords)
+	}
+
+	return words, nil
+}
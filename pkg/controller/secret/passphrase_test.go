@@ -0,0 +1,63 @@
+package secret_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+func TestGeneratePassphraseDefaults(t *testing.T) {
+	value, err := secret.GeneratePassphrase(&secret.PassphraseConstraints{})
+	require.NoError(t, err)
+
+	// some words of the wordlist contain the default separator
+	require.GreaterOrEqual(t, len(strings.Split(string(value), secret.DefaultPassphraseSeparator)), secret.DefaultPassphraseWords)
+	require.Equal(t, strings.ToLower(string(value)), string(value))
+}
+
+func TestGeneratePassphraseOptions(t *testing.T) {
+	value, err := secret.GeneratePassphrase(&secret.PassphraseConstraints{
+		Words:      4,
+		Separator:  " ",
+		Capitalize: true,
+		Digit:      true,
+	})
+	require.NoError(t, err)
+
+	words := strings.Split(string(value), " ")
+	require.Len(t, words, 4)
+	for _, word := range words {
+		require.True(t, unicode.IsUpper([]rune(word)[0]), word)
+	}
+	require.Equal(t, 1, countCharacters(string(value), unicode.IsDigit))
+}
+
+func TestPassphraseConstraintsValidate(t *testing.T) {
+	require.NoError(t, (&secret.PassphraseConstraints{Words: 8}).Validate())
+	require.NoError(t, (&secret.PassphraseConstraints{}).Validate())
+	require.Error(t, (&secret.PassphraseConstraints{Words: -1}).Validate())
+	require.Error(t, (&secret.PassphraseConstraints{Words: 1000}).Validate())
+}
+
+func TestGeneratePassphraseSecretFromAnnotations(t *testing.T) {
+	in := newStringTestSecret("password", map[string]string{
+		secret.AnnotationSecretEncoding:      secret.EncodingPassphrase,
+		secret.AnnotationPassphraseWords:     "5",
+		secret.AnnotationPassphraseSeparator: ".",
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+
+	require.Len(t, strings.Split(string(out.Data["password"]), "."), 5)
+}
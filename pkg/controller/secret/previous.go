@@ -0,0 +1,140 @@
+package secret
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SecretFieldPreviousSuffix is appended to a key to get the key its previous value is kept in
+const SecretFieldPreviousSuffix = ".previous"
+
+// ParseGracePeriod parses the duration previous values are kept for after regeneration. Zero is returned
+// if value is empty, meaning previous values are not kept.
+func ParseGracePeriod(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	gracePeriod, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if gracePeriod <= 0 {
+		return 0, fmt.Errorf("grace period %s must be positive", value)
+	}
+
+	return gracePeriod, nil
+}
+
+// KeepPreviousValue copies the current value of key in data to its companion key and reports whether there
+// was a value to keep
+func KeepPreviousValue(data map[string][]byte, key string) bool {
+	if len(data[key]) == 0 {
+		return false
+	}
+
+	data[key+SecretFieldPreviousSuffix] = data[key]
+
+	return true
+}
+
+// KeepPreviousValues keeps the current values of all keys in data like KeepPreviousValue and reports whether there
+// was any value to keep
+func KeepPreviousValues(data map[string][]byte, keys ...string) bool {
+	kept := false
+	for _, key := range keys {
+		if KeepPreviousValue(data, key) {
+			kept = true
+		}
+	}
+
+	return kept
+}
+
+// SetPreviousValuesExpiry records on instance that its previous values expire after gracePeriod
+func SetPreviousValuesExpiry(instance *corev1.Secret, gracePeriod time.Duration) {
+	if instance.Annotations == nil {
+		instance.Annotations = make(map[string]string)
+	}
+
+	instance.Annotations[AnnotationSecretPreviousExpiry] = time.Now().Add(gracePeriod).Format(time.RFC3339)
+}
+
+// PrunePreviousValues removes the previous values of keys from instance once their grace period has passed
+func PrunePreviousValues(instance *corev1.Secret, keys []string) {
+	if !PreviousValuesExpired(instance) {
+		return
+	}
+
+	for _, key := range keys {
+		delete(instance.Data, key+SecretFieldPreviousSuffix)
+	}
+	delete(instance.Annotations, AnnotationSecretPreviousExpiry)
+}
+
+// PreviousValuesExpired checks whether instance has previous values whose grace period has passed
+func PreviousValuesExpired(instance *corev1.Secret) bool {
+	if _, ok := instance.Annotations[AnnotationSecretPreviousExpiry]; !ok {
+		return false
+	}
+
+	return PreviousValuesExpireIn(instance) == 0
+}
+
+// PreviousValuesExpireIn returns the duration until the previous values of instance expire. Zero is returned
+// if instance has no previous values or they have already expired.
+func PreviousValuesExpireIn(instance *corev1.Secret) time.Duration {
+	expiresAt, err := time.Parse(time.RFC3339, instance.Annotations[AnnotationSecretPreviousExpiry])
+	if err != nil {
+		return 0
+	}
+
+	if until := time.Until(expiresAt); until > 0 {
+		return until
+	}
+
+	return 0
+}
+
+// EarliestRequeue returns the shortest of the given requeue durations, ignoring zero durations
+func EarliestRequeue(durations ...time.Duration) time.Duration {
+	var earliest time.Duration
+	for _, d := range durations {
+		if d > 0 && (earliest == 0 || d < earliest) {
+			earliest = d
+		}
+	}
+
+	return earliest
+}
+
+// onlyPreviousValuesPruned checks whether desired only differs from instance by previous values pruned by generator
+func onlyPreviousValuesPruned(generator Generator, instance, desired *corev1.Secret) bool {
+	if _, ok := instance.Annotations[AnnotationSecretPreviousExpiry]; !ok {
+		return false
+	}
+
+	return reflect.DeepEqual(withoutPreviousValues(generator, instance), withoutPreviousValues(generator, desired))
+}
+
+// withoutPreviousValues returns a copy of instance without previous values and their expiry
+func withoutPreviousValues(generator Generator, instance *corev1.Secret) *corev1.Secret {
+	s := instance.DeepCopy()
+
+	delete(s.Annotations, AnnotationSecretPreviousExpiry)
+	for key := range s.Data {
+		if strings.HasSuffix(key, SecretFieldPreviousSuffix) {
+			delete(s.Data, key)
+		}
+	}
+
+	if pruner, ok := generator.(previousValuesPruner); ok {
+		pruner.prunePreviousValues(s.Data)
+	}
+
+	return s
+}
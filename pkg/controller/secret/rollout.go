@@ -0,0 +1,136 @@
+package secret
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TriggerRollouts restarts the Deployments, StatefulSets and DaemonSets in the namespace of instance that reference it,
+// by setting the restarted-at annotation on their pod templates. Only workloads that opted in, either by themselves or
+// through instance, are restarted.
+func TriggerRollouts(ctx context.Context, c client.Client, logger logr.Logger, instance *corev1.Secret) error {
+	return TriggerRolloutsWithOptIn(ctx, c, logger, instance, RolloutEnabled(instance.Annotations))
+}
+
+// TriggerRolloutsWithOptIn works like TriggerRollouts, but all referencing workloads are restarted if secretOptIn is set,
+// e.g. because the CR owning instance opted in
+func TriggerRolloutsWithOptIn(ctx context.Context, c client.Client, logger logr.Logger, instance *corev1.Secret, secretOptIn bool) error {
+	restartedAt := time.Now().Format(time.RFC3339)
+
+	deployments := &appsv1.DeploymentList{}
+	if err := c.List(ctx, deployments, client.InNamespace(instance.Namespace)); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		err := triggerRollout(ctx, c, logger.WithValues("deployment", d.Name), d, &d.Spec.Template, instance.Name, secretOptIn, restartedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := c.List(ctx, statefulSets, client.InNamespace(instance.Namespace)); err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		s := &statefulSets.Items[i]
+		err := triggerRollout(ctx, c, logger.WithValues("statefulSet", s.Name), s, &s.Spec.Template, instance.Name, secretOptIn, restartedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	daemonSets := &appsv1.DaemonSetList{}
+	if err := c.List(ctx, daemonSets, client.InNamespace(instance.Namespace)); err != nil {
+		return err
+	}
+	for i := range daemonSets.Items {
+		d := &daemonSets.Items[i]
+		err := triggerRollout(ctx, c, logger.WithValues("daemonSet", d.Name), d, &d.Spec.Template, instance.Name, secretOptIn, restartedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// workload is implemented by Deployments, StatefulSets and DaemonSets
+type workload interface {
+	runtime.Object
+	GetAnnotations() map[string]string
+}
+
+// triggerRollout patches the pod template of obj, if it references the secret secretName and opted in to rollouts
+func triggerRollout(ctx context.Context, c client.Client, logger logr.Logger, obj workload, template *corev1.PodTemplateSpec,
+	secretName string, secretOptIn bool, restartedAt string) error {
+	if !secretOptIn && !RolloutEnabled(obj.GetAnnotations()) {
+		return nil
+	}
+
+	if !ReferencesSecret(&template.Spec, secretName) {
+		return nil
+	}
+
+	patch := client.MergeFrom(obj.DeepCopyObject())
+
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[AnnotationRestartedAt] = restartedAt
+
+	logger.Info("triggering rollout of workload referencing secret")
+
+	return c.Patch(ctx, obj, patch)
+}
+
+// RolloutEnabled checks whether the rollout-on-change annotation is set to true in annotations
+func RolloutEnabled(annotations map[string]string) bool {
+	return annotations[AnnotationRolloutOnChange] == "true"
+}
+
+// ReferencesSecret checks whether spec references the secret secretName in its volumes, image pull secrets
+// or the environment of its containers
+func ReferencesSecret(spec *corev1.PodSpec, secretName string) bool {
+	for _, ref := range spec.ImagePullSecrets {
+		if ref.Name == secretName {
+			return true
+		}
+	}
+
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == secretName {
+			return true
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil && source.Secret.Name == secretName {
+					return true
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil && envFrom.SecretRef.Name == secretName {
+				return true
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == secretName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,174 @@
+package secret_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+func newRolloutTestDeployment(secretName string, annotations map[string]string) *appsv1.Deployment {
+	labels := map[string]string{
+		labelSecretGeneratorTest: "yes",
+	}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        getSecretName(),
+			Namespace:   "default",
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "test",
+							Image: "busybox",
+							EnvFrom: []corev1.EnvFromSource{
+								{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: secretName}}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func createRolloutTestDeployment(t *testing.T, d *appsv1.Deployment) {
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), d))
+	t.Cleanup(func() {
+		_ = mgr.GetClient().Delete(context.TODO(), d)
+	})
+}
+
+func getRolloutTestDeployment(t *testing.T, d *appsv1.Deployment) *appsv1.Deployment {
+	out := &appsv1.Deployment{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: d.Name, Namespace: d.Namespace}, out))
+
+	return out
+}
+
+func TestRolloutIsTriggeredForReferencingWorkloads(t *testing.T) {
+	s := newStringTestSecret("testfield", map[string]string{
+		secret.AnnotationRolloutOnChange: "true",
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), s))
+
+	referencing := newRolloutTestDeployment(s.Name, nil)
+	createRolloutTestDeployment(t, referencing)
+
+	other := newRolloutTestDeployment("other", nil)
+	createRolloutTestDeployment(t, other)
+
+	doReconcile(t, s, false)
+
+	out := getRolloutTestDeployment(t, referencing)
+	require.NotEmpty(t, out.Spec.Template.Annotations[secret.AnnotationRestartedAt])
+
+	out = getRolloutTestDeployment(t, other)
+	require.Empty(t, out.Spec.Template.Annotations[secret.AnnotationRestartedAt])
+}
+
+func TestRolloutRequiresOptIn(t *testing.T) {
+	s := newStringTestSecret("testfield", nil, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), s))
+
+	optedOut := newRolloutTestDeployment(s.Name, nil)
+	createRolloutTestDeployment(t, optedOut)
+
+	optedIn := newRolloutTestDeployment(s.Name, map[string]string{
+		secret.AnnotationRolloutOnChange: "true",
+	})
+	createRolloutTestDeployment(t, optedIn)
+
+	doReconcile(t, s, false)
+
+	out := getRolloutTestDeployment(t, optedOut)
+	require.Empty(t, out.Spec.Template.Annotations[secret.AnnotationRestartedAt])
+
+	out = getRolloutTestDeployment(t, optedIn)
+	require.NotEmpty(t, out.Spec.Template.Annotations[secret.AnnotationRestartedAt])
+}
+
+func TestRolloutIsNotTriggeredWithoutChanges(t *testing.T) {
+	s := newStringTestSecret("testfield", map[string]string{
+		secret.AnnotationRolloutOnChange: "true",
+		secret.AnnotationSecretSecure:    "yes",
+	}, "test")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), s))
+
+	d := newRolloutTestDeployment(s.Name, nil)
+	createRolloutTestDeployment(t, d)
+
+	doReconcile(t, s, false)
+
+	out := getRolloutTestDeployment(t, d)
+	require.Empty(t, out.Spec.Template.Annotations[secret.AnnotationRestartedAt])
+}
+
+func TestRolloutIsTriggeredThroughCR(t *testing.T) {
+	in := newStringSecretTestCR(v1alpha1.StringSecretSpec{
+		Fields: []v1alpha1.Field{{FieldName: "test"}},
+	}, "")
+	in.Annotations = map[string]string{
+		secret.AnnotationRolloutOnChange: "true",
+	}
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+	t.Cleanup(func() {
+		_ = mgr.GetClient().Delete(context.TODO(), in)
+	})
+
+	d := newRolloutTestDeployment(in.Name, nil)
+	createRolloutTestDeployment(t, d)
+
+	doReconcileStringSecretController(t, in, false)
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, in))
+	in.Spec.ForceRegenerate = true
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), in))
+
+	doReconcileStringSecretController(t, in, false)
+
+	out := getRolloutTestDeployment(t, d)
+	require.NotEmpty(t, out.Spec.Template.Annotations[secret.AnnotationRestartedAt])
+}
+
+func TestReferencesSecret(t *testing.T) {
+	ref := corev1.LocalObjectReference{Name: "test"}
+
+	specs := map[string]corev1.PodSpec{
+		"imagePullSecrets": {ImagePullSecrets: []corev1.LocalObjectReference{ref}},
+		"volume": {Volumes: []corev1.Volume{
+			{VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "test"}}},
+		}},
+		"projected volume": {Volumes: []corev1.Volume{
+			{VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+				{Secret: &corev1.SecretProjection{LocalObjectReference: ref}},
+			}}}},
+		}},
+		"envFrom": {Containers: []corev1.Container{
+			{EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: ref}}}},
+		}},
+		"env of init container": {InitContainers: []corev1.Container{
+			{Env: []corev1.EnvVar{{ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: ref}}}}},
+		}},
+	}
+
+	for name, spec := range specs {
+		spec := spec
+		require.True(t, secret.ReferencesSecret(&spec, "test"), name)
+		require.False(t, secret.ReferencesSecret(&spec, "other"), name)
+	}
+}
@@ -0,0 +1,78 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"filippo.io/age"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	FieldAgeIdentity  = "identity"
+	FieldAgeRecipient = "recipient"
+)
+
+type AgeKeypairGenerator struct {
+	log logr.Logger
+}
+
+func (ag AgeKeypairGenerator) generateData(instance *corev1.Secret) (reconcile.Result, error) {
+	regenerate := instance.Annotations[AnnotationSecretRegenerate] != ""
+
+	if regenerate {
+		delete(instance.Annotations, AnnotationSecretRegenerate)
+	}
+
+	if err := GenerateAgeKeypairData(ag.log, regenerate, instance.Data); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// GenerateAgeKeypairData generates an age X25519 identity as generated by age-keygen and writes it to data, along
+// with its recipient. An existing identity is kept unless regenerate is set, a missing recipient is derived from it.
+func GenerateAgeKeypairData(logger logr.Logger, regenerate bool, data map[string][]byte) error {
+	identity := data[FieldAgeIdentity]
+	if len(identity) > 0 && !regenerate {
+		return CheckAndRegenAgeRecipient(data, data[FieldAgeRecipient], identity)
+	}
+
+	key, err := age.GenerateX25519Identity()
+	if err != nil {
+		logger.Error(err, "could not generate identity")
+		return err
+	}
+
+	data[FieldAgeIdentity] = []byte(key.String())
+	data[FieldAgeRecipient] = []byte(key.Recipient().String())
+
+	return nil
+}
+
+// CheckAndRegenAgeRecipient checks if the specified recipient has length > 0 and derives it from the given identity
+// otherwise. The identity may also be given in the format of age-keygen, including its comments. The result is written
+// into data
+func CheckAndRegenAgeRecipient(data map[string][]byte, recipient, identity []byte) error {
+	if len(recipient) > 0 {
+		return nil
+	}
+
+	identities, err := age.ParseIdentities(bytes.NewReader(identity))
+	if err != nil {
+		return fmt.Errorf("could not parse identity: %w", err)
+	}
+
+	key, ok := identities[0].(*age.X25519Identity)
+	if len(identities) != 1 || !ok {
+		return errors.New("identity must contain exactly one X25519 identity")
+	}
+	data[FieldAgeRecipient] = []byte(key.Recipient().String())
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package secret_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+// verifyAgeKeypair verifies that data can be encrypted to the recipient in data and decrypted with its identity
+func verifyAgeKeypair(t *testing.T, data map[string][]byte) {
+	require.True(t, strings.HasPrefix(string(data[secret.FieldAgeIdentity]), "AGE-SECRET-KEY-1"))
+	require.True(t, strings.HasPrefix(string(data[secret.FieldAgeRecipient]), "age1"))
+
+	identity, err := age.ParseX25519Identity(string(data[secret.FieldAgeIdentity]))
+	require.NoError(t, err)
+	recipient, err := age.ParseX25519Recipient(string(data[secret.FieldAgeRecipient]))
+	require.NoError(t, err)
+
+	encrypted := &bytes.Buffer{}
+	w, err := age.Encrypt(encrypted, recipient)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("dump"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := age.Decrypt(encrypted, identity)
+	require.NoError(t, err)
+	decrypted, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "dump", string(decrypted))
+}
+
+func TestGenerateAgeKeypairData(t *testing.T) {
+	data := make(map[string][]byte)
+
+	require.NoError(t, secret.GenerateAgeKeypairData(logf.Log, false, data))
+	verifyAgeKeypair(t, data)
+
+	identity := string(data[secret.FieldAgeIdentity])
+	recipient := string(data[secret.FieldAgeRecipient])
+
+	// a missing recipient is derived from the existing identity
+	delete(data, secret.FieldAgeRecipient)
+	require.NoError(t, secret.GenerateAgeKeypairData(logf.Log, false, data))
+	require.Equal(t, identity, string(data[secret.FieldAgeIdentity]))
+	require.Equal(t, recipient, string(data[secret.FieldAgeRecipient]))
+
+	// identities can be imported in the format of age-keygen
+	data = map[string][]byte{
+		secret.FieldAgeIdentity: []byte("# created: 2020-04-03T14:07:47+02:00\n# public key: " + recipient + "\n" + identity + "\n"),
+	}
+	require.NoError(t, secret.GenerateAgeKeypairData(logf.Log, false, data))
+	require.Equal(t, recipient, string(data[secret.FieldAgeRecipient]))
+
+	require.NoError(t, secret.GenerateAgeKeypairData(logf.Log, true, data))
+	verifyAgeKeypair(t, data)
+	require.NotEqual(t, identity, string(data[secret.FieldAgeIdentity]))
+
+	data = map[string][]byte{secret.FieldAgeIdentity: []byte("invalid")}
+	require.Error(t, secret.GenerateAgeKeypairData(logf.Log, false, data))
+}
+
+func TestAgeKeypairIsGenerated(t *testing.T) {
+	in := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSecretName(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+			Annotations: map[string]string{
+				secret.AnnotationSecretType: string(secret.TypeAgeKeypair),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{},
+	}
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	verifyAgeKeypair(t, out.Data)
+}
@@ -1,11 +1,15 @@
 package secret
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 // Ingress basic auth secret field
@@ -13,6 +17,12 @@ const FieldBasicAuthIngress = "auth"
 const FieldBasicAuthUsername = "username"
 const FieldBasicAuthPassword = "password"
 
+// BasicAuthRegeneratedFields are the keys of single-user credentials that change when the password is regenerated
+var BasicAuthRegeneratedFields = []string{FieldBasicAuthIngress, FieldBasicAuthPassword}
+
+// suffix of the keys holding the passwords of the users of multi-user basic auth credentials
+const fieldBasicAuthUserPasswordSuffix = ".password"
+
 type BasicAuthGenerator struct {
 	log logr.Logger
 }
@@ -21,14 +31,42 @@ type BasicAuthConstraints struct {
 	Username string
 	Encoding string
 	Length   string
+	// HashAlgorithm is the algorithm the password is hashed with in the auth field, defaults to DefaultHashAlgorithm
+	HashAlgorithm HashAlgorithm
+	// Cost is the cost of bcrypt hashes, 0 selects the default cost
+	Cost int
+	// Users are the users of multi-user credentials, Username must not be set if users are given
+	Users []BasicAuthUser
+}
+
+// BasicAuthUser is a user of multi-user basic auth credentials
+type BasicAuthUser struct {
+	Username string
+	// Password is a fixed password of the user, a password is generated if it is empty
+	Password string
 }
 
 func (bg BasicAuthGenerator) generateData(instance *corev1.Secret) (reconcile.Result, error) {
 	existingAuth := string(instance.Data[FieldBasicAuthIngress])
 	regenerate := instance.Annotations[AnnotationSecretRegenerate] != ""
 
+	hashAlgorithm, cost, err := getHashFromAnnotations(instance.Annotations)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	gracePeriod, err := ParseGracePeriod(instance.Annotations[AnnotationSecretKeepPrevious])
+	if err != nil {
+		bg.log.Error(err, "could not parse grace period for previous values")
+		return reconcile.Result{}, err
+	}
+
+	PrunePreviousValues(instance, BasicAuthRegeneratedFields)
+
 	if len(existingAuth) > 0 && !regenerate {
-		return reconcile.Result{}, nil
+		// the password is kept, but has to be hashed again if the hash algorithm changed
+		_, err = RehashBasicAuthData(bg.log, &BasicAuthConstraints{HashAlgorithm: hashAlgorithm, Cost: cost}, instance.Data)
+		return reconcile.Result{RequeueAfter: PreviousValuesExpireIn(instance)}, err
 	}
 
 	delete(instance.Annotations, AnnotationSecretRegenerate)
@@ -46,12 +84,111 @@ func (bg BasicAuthGenerator) generateData(instance *corev1.Secret) (reconcile.Re
 		return reconcile.Result{}, err
 	}
 
-	err = GenerateBasicAuthData(bg.log, &BasicAuthConstraints{Encoding: encoding, Length: length, Username: username}, instance.Data)
+	keptPrevious := gracePeriod > 0 && KeepPreviousValues(instance.Data, BasicAuthRegeneratedFields...)
+
+	err = GenerateBasicAuthData(bg.log, &BasicAuthConstraints{
+		Encoding:      encoding,
+		Length:        length,
+		Username:      username,
+		HashAlgorithm: hashAlgorithm,
+		Cost:          cost,
+	}, instance.Data)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, nil
+	if keptPrevious {
+		SetPreviousValuesExpiry(instance, gracePeriod)
+	}
+
+	return reconcile.Result{RequeueAfter: PreviousValuesExpireIn(instance)}, nil
+}
+
+// Validate checks whether basic auth credentials can be generated with the given constraints
+func (cons *BasicAuthConstraints) Validate() error {
+	if _, _, err := ParseByteLength(DefaultLength(), cons.Length); err != nil {
+		return err
+	}
+
+	if err := ValidateEncoding(cons.Encoding); err != nil {
+		return err
+	}
+
+	if cons.HashAlgorithm != "" {
+		if err := cons.HashAlgorithm.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := ValidateHashCost(cons.hashAlgorithm(), cons.Cost); err != nil {
+		return err
+	}
+
+	return cons.validateUsers()
+}
+
+// validateUsers checks whether the users of multi-user credentials can be written to a htpasswd file
+func (cons *BasicAuthConstraints) validateUsers() error {
+	if len(cons.Users) > 0 && cons.Username != "" {
+		return errors.New("username and users are mutually exclusive")
+	}
+
+	usernames := make(map[string]bool, len(cons.Users))
+	for _, user := range cons.Users {
+		if user.Username == "" {
+			return errors.New("username of user must not be empty")
+		}
+		if strings.ContainsAny(user.Username, ":\n") {
+			return fmt.Errorf("username %s must not contain colons or line breaks", user.Username)
+		}
+		if errs := validation.IsConfigMapKey(BasicAuthPasswordField(user.Username)); len(errs) > 0 {
+			return fmt.Errorf("username %s can not be used as key: %s", user.Username, strings.Join(errs, ", "))
+		}
+		if usernames[user.Username] {
+			return fmt.Errorf("duplicate user %s", user.Username)
+		}
+		usernames[user.Username] = true
+	}
+
+	return nil
+}
+
+// BasicAuthPasswordField returns the key holding the password of username in multi-user credentials
+func BasicAuthPasswordField(username string) string {
+	return username + fieldBasicAuthUserPasswordSuffix
+}
+
+// BasicAuthUsersFields returns the keys generated for multi-user credentials with the given users
+func BasicAuthUsersFields(users []BasicAuthUser) []string {
+	fields := []string{FieldBasicAuthIngress}
+	for _, user := range users {
+		fields = append(fields, BasicAuthPasswordField(user.Username))
+	}
+
+	return fields
+}
+
+// hashAlgorithm returns the algorithm the password is hashed with
+func (cons *BasicAuthConstraints) hashAlgorithm() HashAlgorithm {
+	if cons.HashAlgorithm == "" {
+		return DefaultHashAlgorithm
+	}
+	return cons.HashAlgorithm
+}
+
+// getHashFromAnnotations returns the hash algorithm and cost configured by annotations
+func getHashFromAnnotations(annotations map[string]string) (HashAlgorithm, int, error) {
+	algorithm := HashAlgorithm(annotations[AnnotationBasicAuthAlgorithm])
+
+	cost := 0
+	if value, ok := annotations[AnnotationBasicAuthCost]; ok {
+		var err error
+		if cost, err = strconv.Atoi(value); err != nil {
+			return "", 0, errors.WithStack(err)
+		}
+	}
+
+	return algorithm, cost, nil
 }
 
 func GenerateBasicAuthData(logger logr.Logger, cons *BasicAuthConstraints, data map[string][]byte) error {
@@ -59,11 +196,26 @@ func GenerateBasicAuthData(logger logr.Logger, cons *BasicAuthConstraints, data
 		cons.Username = "admin"
 	}
 
+	password, entry, err := generateHtpasswdCredentials(logger, cons)
+	if err != nil {
+		return err
+	}
+
+	data[FieldBasicAuthIngress] = []byte(entry)
+	data[FieldBasicAuthUsername] = []byte(cons.Username)
+	data[FieldBasicAuthPassword] = password
+
+	return nil
+}
+
+// generateHtpasswdCredentials generates a random password for the user of cons and returns it along with the
+// htpasswd entry of the user
+func generateHtpasswdCredentials(logger logr.Logger, cons *BasicAuthConstraints) ([]byte, string, error) {
 	parsedLen, isByteLength, err := ParseByteLength(DefaultLength(), cons.Length)
 	if err != nil {
 		logger.Error(err, "could not parse length for new random string")
 
-		return err
+		return nil, "", err
 	}
 
 	var password []byte
@@ -71,20 +223,119 @@ func GenerateBasicAuthData(logger logr.Logger, cons *BasicAuthConstraints, data
 	if err != nil {
 		logger.Error(err, "could not generate random string")
 
-		return err
+		return nil, "", err
 	}
 
-	var passwordHash []byte
-	passwordHash, err = bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+	var passwordHash string
+	passwordHash, err = HashPassword(password, cons.hashAlgorithm(), cons.Cost)
 	if err != nil {
 		logger.Error(err, "could not hash random string")
 
+		return nil, "", err
+	}
+
+	return password, cons.Username + ":" + passwordHash, nil
+}
+
+// RehashBasicAuthData hashes the existing password in data again, if the hash in the auth field was not computed
+// with the hash algorithm and cost of cons. The password itself is not changed. Returns whether the password was
+// hashed again.
+func RehashBasicAuthData(logger logr.Logger, cons *BasicAuthConstraints, data map[string][]byte) (bool, error) {
+	username, hash, ok := strings.Cut(string(data[FieldBasicAuthIngress]), ":")
+	if !ok || HashMatches(hash, cons.hashAlgorithm(), cons.Cost) {
+		return false, nil
+	}
+
+	password := data[FieldBasicAuthPassword]
+	if len(password) == 0 {
+		logger.Info("hash algorithm changed, but password is not available to hash it again", "algorithm", cons.hashAlgorithm())
+		return false, nil
+	}
+
+	passwordHash, err := HashPassword(password, cons.hashAlgorithm(), cons.Cost)
+	if err != nil {
+		logger.Error(err, "could not hash existing password")
+		return false, err
+	}
+
+	logger.Info("hashed existing password again", "algorithm", cons.hashAlgorithm())
+	data[FieldBasicAuthIngress] = []byte(username + ":" + passwordHash)
+
+	return true, nil
+}
+
+// GenerateBasicAuthUsersData writes the htpasswd entries of all users of cons to the auth field of data, one line
+// per user, and the password of each user to the key returned by BasicAuthPasswordField. Existing passwords are
+// kept unless regenerate is set, existing hashes are kept as long as they match the password, hash algorithm and
+// cost. The passwords of users that were removed from cons are deleted from data.
+func GenerateBasicAuthUsersData(logger logr.Logger, cons *BasicAuthConstraints, data map[string][]byte, regenerate bool) error {
+	parsedLen, isByteLength, err := ParseByteLength(DefaultLength(), cons.Length)
+	if err != nil {
+		logger.Error(err, "could not parse length for new random string")
+
 		return err
 	}
 
-	data[FieldBasicAuthIngress] = append([]byte(cons.Username+":"), passwordHash...)
-	data[FieldBasicAuthUsername] = []byte(cons.Username)
-	data[FieldBasicAuthPassword] = password
+	existingHashes := parseHtpasswd(data[FieldBasicAuthIngress])
+	usernames := make(map[string]bool, len(cons.Users))
+
+	lines := make([]string, 0, len(cons.Users))
+	for _, user := range cons.Users {
+		usernames[user.Username] = true
+		field := BasicAuthPasswordField(user.Username)
+
+		password := []byte(user.Password)
+		if len(password) == 0 {
+			password = data[field]
+		}
+		if user.Password == "" && (len(password) == 0 || regenerate) {
+			password, err = GenerateRandomString(parsedLen, cons.Encoding, isByteLength)
+			if err != nil {
+				logger.Error(err, "could not generate random string")
+
+				return err
+			}
+		}
+
+		hash, ok := existingHashes[user.Username]
+		if !ok || !HashMatches(hash, cons.hashAlgorithm(), cons.Cost) || !VerifyPassword(hash, password) {
+			hash, err = HashPassword(password, cons.hashAlgorithm(), cons.Cost)
+			if err != nil {
+				logger.Error(err, "could not hash password", "user", user.Username)
+
+				return err
+			}
+		}
+
+		data[field] = password
+		lines = append(lines, user.Username+":"+hash)
+	}
+
+	for username := range existingHashes {
+		if !usernames[username] {
+			logger.Info("removing password of deleted user", "user", username)
+			delete(data, BasicAuthPasswordField(username))
+			delete(data, BasicAuthPasswordField(username)+SecretFieldPreviousSuffix)
+		}
+	}
+
+	// the fields of single-user credentials are removed, as they do not match the auth field anymore
+	delete(data, FieldBasicAuthUsername)
+	delete(data, FieldBasicAuthPassword)
+
+	data[FieldBasicAuthIngress] = []byte(strings.Join(lines, "\n"))
 
 	return nil
 }
+
+// parseHtpasswd returns the hashes of the users of a htpasswd file
+func parseHtpasswd(htpasswd []byte) map[string]string {
+	hashes := make(map[string]string)
+	for _, line := range strings.Split(string(htpasswd), "\n") {
+		if username, hash, ok := strings.Cut(strings.TrimSpace(line), ":"); ok {
+			hashes[username] = hash
+		}
+	}
+
+	return hashes
+}
@@ -139,6 +139,36 @@ func TestGenerateBasicAuthRegenerate(t *testing.T) {
 	}
 }
 
+func TestGenerateBasicAuthKeepPrevious(t *testing.T) {
+	in := newBasicAuthTestSecret(map[string]string{
+		secret.AnnotationSecretKeepPrevious: "1h",
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), client.ObjectKey{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+	require.NotContains(t, out.Data, secret.FieldBasicAuthPassword+secret.SecretFieldPreviousSuffix)
+
+	out.Annotations[secret.AnnotationSecretRegenerate] = "yes"
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), out))
+
+	doReconcile(t, out, false)
+
+	outNew := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), client.ObjectKey{
+		Name:      in.Name,
+		Namespace: in.Namespace}, outNew))
+
+	require.NotEqual(t, out.Data[secret.FieldBasicAuthPassword], outNew.Data[secret.FieldBasicAuthPassword])
+	require.Equal(t, out.Data[secret.FieldBasicAuthPassword], outNew.Data[secret.FieldBasicAuthPassword+secret.SecretFieldPreviousSuffix])
+	require.Equal(t, out.Data[secret.FieldBasicAuthIngress], outNew.Data[secret.FieldBasicAuthIngress+secret.SecretFieldPreviousSuffix])
+	require.Contains(t, outNew.Annotations, secret.AnnotationSecretPreviousExpiry)
+}
+
 func TestGenerateBasicAuthNoRegenerate(t *testing.T) {
 	in := newBasicAuthTestSecret(map[string]string{
 		secret.AnnotationBasicAuthUsername: "test123",
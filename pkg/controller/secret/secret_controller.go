@@ -11,6 +11,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -22,6 +23,9 @@ import (
 
 const ByteSuffix = "b"
 
+// EventSource is the component events of the operator are reported from
+const EventSource = "kubernetes-secret-generator"
+
 var log = logf.Log.WithName("controller_secret")
 
 func RegenerateInsecure() bool {
@@ -40,6 +44,17 @@ func SSHKeyLength() int {
 	return viper.GetInt("ssh-key-length")
 }
 
+func SSHKeyAlgorithm() KeyAlgorithm {
+	if algorithm := viper.GetString("ssh-key-algorithm"); algorithm != "" {
+		return KeyAlgorithm(algorithm)
+	}
+	return KeyAlgorithmRSA
+}
+
+func CertificateRenewBefore() time.Duration {
+	return viper.GetDuration("certificate-renew-before")
+}
+
 // Add creates a new Secret Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
@@ -48,7 +63,7 @@ func Add(mgr manager.Manager) error {
 
 // NewReconciler returns a new reconcile.Reconciler
 func NewReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileSecret{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileSecret{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor(EventSource)}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -75,8 +90,9 @@ var _ reconcile.Reconciler = &ReconcileSecret{}
 type ReconcileSecret struct {
 	// This Client, initialized using mgr.Client() above, is a split Client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a Secret object and makes changes based on the state read
@@ -104,47 +120,42 @@ func (r *ReconcileSecret) Reconcile(request reconcile.Request) (reconcile.Result
 
 	desired := instance.DeepCopy()
 
-	sType := Type(desired.Annotations[AnnotationSecretType])
-	if err = sType.Validate(); err != nil {
-		if _, ok := desired.Annotations[AnnotationSecretAutoGenerate]; !ok && sType == "" {
-			// return if secret has no type and no autogenerate annotation
-			return reconcile.Result{}, nil
-		}
-
-		// keep backwards compatibility by defaulting to string type
-		desired.Annotations[AnnotationSecretType] = string(TypeString)
-		sType = TypeString
+	sType, ok := secretType(desired)
+	if !ok {
+		// return if secret has no type and no autogenerate annotation
+		return reconcile.Result{}, nil
 	}
 
 	reqLogger = reqLogger.WithValues("type", sType)
 	reqLogger.Info("instance is autogenerated")
 
+	// annotations are validated before the type is defaulted, so an unknown type is rejected instead of
+	// generating a string
+	if err = ValidateAnnotations(desired.Annotations); err != nil {
+		reqLogger.Error(err, "invalid annotation")
+		r.recorder.Event(instance, corev1.EventTypeWarning, EventReasonInvalidAnnotation, err.Error())
+		return reconcile.Result{}, err
+	}
+	desired.Annotations[AnnotationSecretType] = string(sType)
+
 	if desired.Data == nil {
 		desired.Data = make(map[string][]byte)
 	}
 
-	var generator Generator
-	switch sType {
-	case TypeSSHKeypair:
-		generator = SSHKeypairGenerator{
-			log: reqLogger.WithValues("type", TypeSSHKeypair),
-		}
-	case TypeString:
-		generator = StringGenerator{
-			log: reqLogger.WithValues("type", TypeString),
-		}
-	case TypeBasicAuth:
-		generator = BasicAuthGenerator{
-			log: reqLogger.WithValues("type", TypeBasicAuth),
-		}
-	default:
-		// default case to prevent potential nil-pointer
+	generator := newGenerator(sType, reqLogger, r.client)
+	if generator == nil {
+		// prevent potential nil-pointer
 		reqLogger.Error(errstd.New("SecretTypeNotSpecified"), "Secret type was not specified")
 		return reconcile.Result{Requeue: true}, errstd.New("SecretTypeNotSpecified")
 	}
 
 	res, err := generator.generateData(desired)
+	if err == nil {
+		// templates are rendered after generation, so they reflect regenerated values
+		err = RenderTemplates(GetTemplatesFromAnnotations(desired.Annotations), desired.Data)
+	}
 	if err != nil {
+		r.recorder.Event(instance, corev1.EventTypeWarning, EventReasonGenerationFailed, err.Error())
 		return res, err
 	}
 
@@ -152,15 +163,27 @@ func (r *ReconcileSecret) Reconcile(request reconcile.Request) (reconcile.Result
 		!reflect.DeepEqual(instance.Data, desired.Data) {
 		reqLogger.Info("updating secret")
 
-		desired.Annotations[AnnotationSecretAutoGeneratedAt] = time.Now().Format(time.RFC3339)
+		// pruning previous values does not change the generated values themselves
+		if !onlyPreviousValuesPruned(generator, instance, desired) {
+			desired.Annotations[AnnotationSecretAutoGeneratedAt] = time.Now().Format(time.RFC3339)
+		}
 		err := r.client.Update(context.Background(), desired)
 		if err != nil {
 			reqLogger.Error(err, "could not update secret")
 			return reconcile.Result{Requeue: true}, err
 		}
+
+		if !reflect.DeepEqual(instance.Data, desired.Data) && !onlyPreviousValuesPruned(generator, instance, desired) {
+			RecordGenerationEvents(r.recorder, desired, dataKeys(desired.Data), instance.Data, desired.Data)
+
+			// a failed rollout must not fail the reconciliation, as the changed data would not be detected again
+			if err := TriggerRollouts(context.Background(), r.client, reqLogger, desired); err != nil {
+				reqLogger.Error(err, "could not trigger rollouts of workloads referencing secret")
+			}
+		}
 	}
 
-	return reconcile.Result{}, nil
+	return res, nil
 }
 
 func GetLengthFromAnnotation(fallback int, annotations map[string]string) (string, error) {
@@ -157,6 +157,42 @@ func reset() {
 			panic(err)
 		}
 	}
+
+	certificateList := &v1alpha1.CertificateList{}
+	err = mgr.GetClient().List(context.TODO(),
+		certificateList,
+		client.MatchingLabels(map[string]string{
+			labelSecretGeneratorTest: "yes",
+		}),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, s := range certificateList.Items {
+		err = mgr.GetClient().Delete(context.TODO(), &s)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	certificateAuthorityList := &v1alpha1.CertificateAuthorityList{}
+	err = mgr.GetClient().List(context.TODO(),
+		certificateAuthorityList,
+		client.MatchingLabels(map[string]string{
+			labelSecretGeneratorTest: "yes",
+		}),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, s := range certificateAuthorityList.Items {
+		err = mgr.GetClient().Delete(context.TODO(), &s)
+		if err != nil {
+			panic(err)
+		}
+	}
 }
 
 func doReconcile(t *testing.T, targetSecret *corev1.Secret, isErr bool) {
@@ -211,3 +247,28 @@ func stringInSlice(a string, list []string) bool {
 	}
 	return false
 }
+
+func TestUnknownTypeIsRejected(t *testing.T) {
+	in := &corev1.Secret{
+		Type: corev1.SecretTypeOpaque,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSecretName(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+			Annotations: map[string]string{
+				secret.AnnotationSecretAutoGenerate: "testfield",
+				secret.AnnotationSecretType:         "password",
+			},
+		},
+	}
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, true)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.Empty(t, out.Data, "a string was generated for an unknown type")
+	require.Equal(t, "password", out.Annotations[secret.AnnotationSecretType])
+}
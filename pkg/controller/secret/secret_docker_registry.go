@@ -0,0 +1,155 @@
+package secret
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// FieldDockerConfigJSON holds the docker config with the credentials of the registry
+	FieldDockerConfigJSON = corev1.DockerConfigJsonKey
+	// FieldDockerRegistryHtpasswd holds the htpasswd entry the registry authenticates the user with
+	FieldDockerRegistryHtpasswd = "htpasswd"
+)
+
+// DefaultDockerRegistryUsername is the user of registry credentials if no username is configured
+const DefaultDockerRegistryUsername = "admin"
+
+type DockerRegistryGenerator struct {
+	log logr.Logger
+}
+
+// DockerRegistryConstraints configures the generation of docker registry credentials
+type DockerRegistryConstraints struct {
+	// Server is the registry host the credentials are used for, e.g. registry.example.com:5000
+	Server string
+	// Username is the user of the credentials, defaults to DefaultDockerRegistryUsername
+	Username string
+	Encoding string
+	Length   string
+}
+
+// dockerConfigJSON is the format of the .dockerconfigjson key of kubernetes.io/dockerconfigjson Secrets
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+func (dg DockerRegistryGenerator) generateData(instance *corev1.Secret) (reconcile.Result, error) {
+	regenerate := instance.Annotations[AnnotationSecretRegenerate] != ""
+
+	if regenerate {
+		delete(instance.Annotations, AnnotationSecretRegenerate)
+	}
+
+	length, err := GetLengthFromAnnotation(DefaultLength(), instance.Annotations)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	encoding, err := getEncodingFromAnnotation(DefaultEncoding(), instance.Annotations)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	cons := &DockerRegistryConstraints{
+		Server:   instance.Annotations[AnnotationDockerRegistryServer],
+		Username: instance.Annotations[AnnotationDockerRegistryUser],
+		Encoding: encoding,
+		Length:   length,
+	}
+
+	if err = GenerateDockerRegistryData(dg.log, cons, regenerate, instance.Data); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// Validate checks whether registry credentials can be generated with the given constraints
+func (cons *DockerRegistryConstraints) Validate() error {
+	if cons.Server == "" {
+		return errors.New("registry server must be set")
+	}
+	if strings.ContainsAny(cons.Username, ":\n") {
+		return errors.New("username must not contain colons or line breaks")
+	}
+
+	return cons.basicAuthConstraints().Validate()
+}
+
+// basicAuthConstraints returns the constraints of the htpasswd entry of the credentials. The registry only
+// supports bcrypt hashes.
+func (cons *DockerRegistryConstraints) basicAuthConstraints() *BasicAuthConstraints {
+	username := cons.Username
+	if username == "" {
+		username = DefaultDockerRegistryUsername
+	}
+
+	return &BasicAuthConstraints{
+		Username:      username,
+		Encoding:      cons.Encoding,
+		Length:        cons.Length,
+		HashAlgorithm: HashAlgorithmBcrypt,
+	}
+}
+
+// GenerateDockerRegistryData generates a password for the user of cons unless data already holds one or regenerate
+// is set. The docker config and htpasswd entry are updated whenever the server, username or password change.
+func GenerateDockerRegistryData(logger logr.Logger, cons *DockerRegistryConstraints, regenerate bool, data map[string][]byte) error {
+	if err := cons.Validate(); err != nil {
+		return err
+	}
+
+	basicAuth := cons.basicAuthConstraints()
+	password := data[FieldBasicAuthPassword]
+	entry := string(data[FieldDockerRegistryHtpasswd])
+
+	if regenerate || len(password) == 0 {
+		var err error
+		if password, entry, err = generateHtpasswdCredentials(logger, basicAuth); err != nil {
+			return err
+		}
+	} else if username, hash, ok := strings.Cut(entry, ":"); !ok || username != basicAuth.Username ||
+		!HashMatches(hash, HashAlgorithmBcrypt, 0) || !VerifyPassword(hash, password) {
+		// the username changed or the password was set manually, so the password has to be hashed again
+		hash, err := HashPassword(password, HashAlgorithmBcrypt, 0)
+		if err != nil {
+			logger.Error(err, "could not hash password")
+			return err
+		}
+		entry = basicAuth.Username + ":" + hash
+	}
+
+	config, err := json.Marshal(dockerConfigJSON{
+		Auths: map[string]dockerConfigEntry{
+			cons.Server: {
+				Username: basicAuth.Username,
+				Password: string(password),
+				Auth:     base64.StdEncoding.EncodeToString([]byte(basicAuth.Username + ":" + string(password))),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	data[FieldDockerConfigJSON] = config
+	data[FieldDockerRegistryHtpasswd] = []byte(entry)
+	data[FieldBasicAuthUsername] = []byte(basicAuth.Username)
+	data[FieldBasicAuthPassword] = password
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package secret_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/dockerregistry"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+const testRegistry = "registry.example.com:5000"
+
+// verifyDockerRegistryData verifies that the docker config and htpasswd entry of data match its credentials
+func verifyDockerRegistryData(t *testing.T, data map[string][]byte) {
+	username := string(data[secret.FieldBasicAuthUsername])
+	password := string(data[secret.FieldBasicAuthPassword])
+	require.NotEmpty(t, password)
+
+	config := struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}{}
+	require.NoError(t, json.Unmarshal(data[secret.FieldDockerConfigJSON], &config))
+	require.Len(t, config.Auths, 1)
+	entry := config.Auths[testRegistry]
+	require.Equal(t, username, entry.Username)
+	require.Equal(t, password, entry.Password)
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte(username+":"+password)), entry.Auth)
+
+	user, hash, ok := strings.Cut(string(data[secret.FieldDockerRegistryHtpasswd]), ":")
+	require.True(t, ok)
+	require.Equal(t, username, user)
+	require.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)))
+}
+
+func TestGenerateDockerRegistryData(t *testing.T) {
+	cons := &secret.DockerRegistryConstraints{Server: testRegistry, Encoding: "base64", Length: "20"}
+	data := make(map[string][]byte)
+
+	require.NoError(t, secret.GenerateDockerRegistryData(logf.Log, cons, false, data))
+	verifyDockerRegistryData(t, data)
+	require.Equal(t, secret.DefaultDockerRegistryUsername, string(data[secret.FieldBasicAuthUsername]))
+	password := string(data[secret.FieldBasicAuthPassword])
+	entry := string(data[secret.FieldDockerRegistryHtpasswd])
+
+	// existing credentials are kept
+	require.NoError(t, secret.GenerateDockerRegistryData(logf.Log, cons, false, data))
+	require.Equal(t, password, string(data[secret.FieldBasicAuthPassword]))
+	require.Equal(t, entry, string(data[secret.FieldDockerRegistryHtpasswd]))
+
+	// a changed username keeps the password, but updates config and htpasswd entry
+	cons.Username = testUsername
+	require.NoError(t, secret.GenerateDockerRegistryData(logf.Log, cons, false, data))
+	verifyDockerRegistryData(t, data)
+	require.Equal(t, password, string(data[secret.FieldBasicAuthPassword]))
+	require.Equal(t, testUsername, string(data[secret.FieldBasicAuthUsername]))
+
+	require.NoError(t, secret.GenerateDockerRegistryData(logf.Log, cons, true, data))
+	verifyDockerRegistryData(t, data)
+	require.NotEqual(t, password, string(data[secret.FieldBasicAuthPassword]))
+}
+
+func TestValidateDockerRegistryConstraints(t *testing.T) {
+	require.Error(t, (&secret.DockerRegistryConstraints{}).Validate())
+	require.Error(t, (&secret.DockerRegistryConstraints{Server: testRegistry, Username: "invalid:user"}).Validate())
+	require.NoError(t, (&secret.DockerRegistryConstraints{Server: testRegistry}).Validate())
+}
+
+func TestDockerRegistryIsGenerated(t *testing.T) {
+	in := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSecretName(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+			Annotations: map[string]string{
+				secret.AnnotationSecretType:           string(secret.TypeDockerRegistry),
+				secret.AnnotationDockerRegistryServer: testRegistry,
+				secret.AnnotationDockerRegistryUser:   testUsername,
+			},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte("{}"),
+		},
+	}
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	verifyDockerRegistryData(t, out.Data)
+	require.Equal(t, testUsername, string(out.Data[secret.FieldBasicAuthUsername]))
+}
+
+func newDockerRegistryTestCR(spec v1alpha1.DockerRegistrySpec) *v1alpha1.DockerRegistry {
+	return &v1alpha1.DockerRegistry{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiVersion,
+			Kind:       dockerregistry.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      uuid.New().String(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+		},
+		Spec: spec,
+	}
+}
+
+func doReconcileDockerRegistryController(t *testing.T, cr *v1alpha1.DockerRegistry, isErr bool) {
+	rec := dockerregistry.NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}}
+
+	res, err := rec.Reconcile(req)
+
+	if isErr {
+		require.Error(t, err)
+	} else {
+		require.NoError(t, err)
+	}
+	require.False(t, res.Requeue)
+}
+
+func TestControllerGenerateDockerRegistry(t *testing.T) {
+	in := newDockerRegistryTestCR(v1alpha1.DockerRegistrySpec{Server: testRegistry, Length: "30"})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileDockerRegistryController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.Equal(t, corev1.SecretTypeDockerConfigJson, out.Type)
+	verifyDockerRegistryData(t, out.Data)
+	password := string(out.Data[secret.FieldBasicAuthPassword])
+
+	// the password is kept on further reconciliations
+	doReconcileDockerRegistryController(t, in, false)
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.Equal(t, password, string(out.Data[secret.FieldBasicAuthPassword]))
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestDockerRegistryValidateSpec(t *testing.T) {
+	in := newDockerRegistryTestCR(v1alpha1.DockerRegistrySpec{})
+	require.Error(t, dockerregistry.ValidateSpec(in))
+
+	in.Spec.Server = testRegistry
+	in.Spec.Data = map[string]string{secret.FieldBasicAuthPassword: "secret"}
+	require.Error(t, dockerregistry.ValidateSpec(in))
+
+	in.Spec.Data = map[string]string{"extra": "value"}
+	require.NoError(t, dockerregistry.ValidateSpec(in))
+}
@@ -0,0 +1,361 @@
+package secret
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	FieldJWTPrivateKey = "private.pem"
+	FieldJWTPublicKey  = "public.pem"
+	FieldJWTKeyID      = "kid"
+	FieldJWKS          = "jwks.json"
+)
+
+type JWTAlgorithm string
+
+const (
+	JWTAlgorithmRS256 JWTAlgorithm = "RS256"
+	JWTAlgorithmES256 JWTAlgorithm = "ES256"
+	JWTAlgorithmEdDSA JWTAlgorithm = "EdDSA"
+)
+
+const (
+	DefaultJWTAlgorithm    = JWTAlgorithmRS256
+	DefaultJWTRSAKeyLength = 2048
+)
+
+func (ja JWTAlgorithm) Validate() error {
+	switch ja {
+	case JWTAlgorithmRS256,
+		JWTAlgorithmES256,
+		JWTAlgorithmEdDSA:
+		return nil
+	}
+	return fmt.Errorf("%s is not a valid signing algorithm, must be one of %s, %s, %s", ja, JWTAlgorithmRS256, JWTAlgorithmES256, JWTAlgorithmEdDSA)
+}
+
+// keyAlgorithm returns the algorithm of the keys signing with ja
+func (ja JWTAlgorithm) keyAlgorithm() KeyAlgorithm {
+	switch ja {
+	case JWTAlgorithmES256:
+		return KeyAlgorithmECDSAP256
+	case JWTAlgorithmEdDSA:
+		return KeyAlgorithmEd25519
+	}
+	return KeyAlgorithmRSA
+}
+
+type JWTGenerator struct {
+	log logr.Logger
+}
+
+// JWTConstraints configures the generation of JWT signing keys
+type JWTConstraints struct {
+	Algorithm string
+	// Length is the size of RSA keys in bits
+	Length string
+	// KeepPrevious keeps the public keys of replaced signing keys in the JWKS, so tokens signed by them can still
+	// be validated
+	KeepPrevious bool
+}
+
+// jwk is a public JSON Web Key as defined by RFC 7517
+type jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// jwks is a JSON Web Key Set as defined by RFC 7517
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+func (jg JWTGenerator) generateData(instance *corev1.Secret) (reconcile.Result, error) {
+	regenerate := instance.Annotations[AnnotationSecretRegenerate] != ""
+
+	if regenerate {
+		delete(instance.Annotations, AnnotationSecretRegenerate)
+	}
+
+	rotateAfter, err := getRotateAfterFromAnnotation(instance.Annotations)
+	if err != nil {
+		jg.log.Error(err, "could not parse rotation interval")
+		return reconcile.Result{}, err
+	}
+
+	var rotation time.Time
+	if rotateAfter > 0 {
+		rotation = nextRotationTime(jg.log, instance, rotateAfter)
+		if !time.Now().Before(rotation) {
+			jg.log.Info("rotation interval has passed, rotating signing key", "rotateAfter", rotateAfter)
+			regenerate = true
+		}
+	}
+
+	gracePeriod, err := ParseGracePeriod(instance.Annotations[AnnotationSecretKeepPrevious])
+	if err != nil {
+		jg.log.Error(err, "could not parse grace period for previous values")
+		return reconcile.Result{}, err
+	}
+
+	if PreviousValuesExpired(instance) {
+		jg.prunePreviousValues(instance.Data)
+	}
+	PrunePreviousValues(instance, nil)
+
+	cons := &JWTConstraints{
+		Algorithm:    instance.Annotations[AnnotationJWTAlgorithm],
+		Length:       instance.Annotations[AnnotationSecretLength],
+		KeepPrevious: gracePeriod > 0,
+	}
+
+	// keys of another algorithm are left behind if the algorithm annotation is changed
+	if !regenerate && len(instance.Data[FieldJWTPrivateKey]) > 0 && !cons.signingKeyMatches(instance.Data) {
+		jg.log.Info("signing key does not match signing algorithm, regenerating signing key", "algorithm", cons.algorithm())
+		regenerate = true
+	}
+
+	keptPrevious := regenerate && cons.KeepPrevious && len(instance.Data[FieldJWTPrivateKey]) > 0
+	generated := regenerate || len(instance.Data[FieldJWTPrivateKey]) == 0
+
+	if err = GenerateJWTData(jg.log, cons, regenerate, instance.Data); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	if keptPrevious {
+		SetPreviousValuesExpiry(instance, gracePeriod)
+	}
+
+	var requeueAfter time.Duration
+	if rotateAfter > 0 {
+		// new keys are rotated rotateAfter from now, as the generation time is updated with the secret
+		requeueAfter = rotateAfter
+		if !generated && !rotation.IsZero() {
+			requeueAfter = time.Until(rotation)
+		}
+	}
+
+	return reconcile.Result{RequeueAfter: EarliestRequeue(requeueAfter, PreviousValuesExpireIn(instance))}, nil
+}
+
+// prunePreviousValues removes the public keys of previous signing keys, which are kept in the JWKS instead of
+// companion keys
+func (jg JWTGenerator) prunePreviousValues(data map[string][]byte) {
+	PruneJWKS(data)
+}
+
+// Validate checks whether signing keys can be generated with the given constraints
+func (cons *JWTConstraints) Validate() error {
+	if cons.Algorithm != "" {
+		if err := JWTAlgorithm(cons.Algorithm).Validate(); err != nil {
+			return err
+		}
+	}
+
+	// the length is only taken into account for RSA keys
+	if cons.algorithm() != JWTAlgorithmRS256 {
+		return nil
+	}
+
+	_, err := ParseRSAKeyLength(DefaultJWTRSAKeyLength, cons.Length)
+
+	return err
+}
+
+// algorithm returns the signing algorithm of cons, which defaults to DefaultJWTAlgorithm
+func (cons *JWTConstraints) algorithm() JWTAlgorithm {
+	if cons.Algorithm == "" {
+		return DefaultJWTAlgorithm
+	}
+	return JWTAlgorithm(cons.Algorithm)
+}
+
+// signingKeyMatches checks whether the signing key in data can sign with the algorithm of cons. Unparsable keys
+// are reported as matching, so they are rejected when the key is read instead of being replaced.
+func (cons *JWTConstraints) signingKeyMatches(data map[string][]byte) bool {
+	key, err := ParsePrivateKeyPEM(data[FieldJWTPrivateKey])
+	if err != nil {
+		return true
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return cons.algorithm() == JWTAlgorithmRS256
+	case *ecdsa.PrivateKey:
+		return cons.algorithm() == JWTAlgorithmES256 && k.Curve == elliptic.P256()
+	case ed25519.PrivateKey:
+		return cons.algorithm() == JWTAlgorithmEdDSA
+	}
+
+	return false
+}
+
+// GenerateJWTData generates a signing key and writes it to data in PKCS#8 format, along with its public key, its
+// key ID and a JWKS holding the public key. The key is only replaced if regenerate is set or data holds no key,
+// the public values are always derived from it again. Public keys of previous signing keys stay in the JWKS if
+// KeepPrevious is set, they have to be removed by PruneJWKS.
+func GenerateJWTData(logger logr.Logger, cons *JWTConstraints, regenerate bool, data map[string][]byte) error {
+	if err := cons.Validate(); err != nil {
+		return err
+	}
+
+	// an unparsable JWKS is replaced, as it can not be used to validate tokens anyway
+	keySet := &jwks{}
+	if err := json.Unmarshal(data[FieldJWKS], keySet); err != nil {
+		keySet = &jwks{}
+	}
+
+	var key crypto.Signer
+	var err error
+	if len(data[FieldJWTPrivateKey]) > 0 && !regenerate {
+		if key, err = ParsePrivateKeyPEM(data[FieldJWTPrivateKey]); err != nil {
+			logger.Error(err, "could not parse signing key")
+			return err
+		}
+	} else {
+		if !cons.KeepPrevious {
+			keySet.Keys = nil
+		}
+		if key, err = generateJWTSigningKey(cons); err != nil {
+			logger.Error(err, "could not generate signing key")
+			return err
+		}
+		if data[FieldJWTPrivateKey], err = encodeTLSPrivateKey(key, string(PrivateKeyFormatPKCS8)); err != nil {
+			return err
+		}
+	}
+
+	current, err := newJWK(key.Public())
+	if err != nil {
+		return err
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return err
+	}
+
+	keys := []jwk{current}
+	for _, previous := range keySet.Keys {
+		if previous.Kid != current.Kid {
+			keys = append(keys, previous)
+		}
+	}
+
+	encoded, err := json.Marshal(jwks{Keys: keys})
+	if err != nil {
+		return err
+	}
+
+	data[FieldJWTPublicKey] = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})
+	data[FieldJWTKeyID] = []byte(current.Kid)
+	data[FieldJWKS] = encoded
+
+	return nil
+}
+
+// PruneJWKS removes the public keys of previous signing keys from the JWKS in data
+func PruneJWKS(data map[string][]byte) {
+	keySet := &jwks{}
+	if err := json.Unmarshal(data[FieldJWKS], keySet); err != nil {
+		return
+	}
+
+	kid := string(data[FieldJWTKeyID])
+	keys := make([]jwk, 0, 1)
+	for _, key := range keySet.Keys {
+		if key.Kid == kid {
+			keys = append(keys, key)
+		}
+	}
+
+	if encoded, err := json.Marshal(jwks{Keys: keys}); err == nil {
+		data[FieldJWKS] = encoded
+	}
+}
+
+// generateJWTSigningKey generates a new private key for the algorithm of cons
+func generateJWTSigningKey(cons *JWTConstraints) (crypto.Signer, error) {
+	var length int
+	if cons.algorithm() == JWTAlgorithmRS256 {
+		var err error
+		if length, err = ParseRSAKeyLength(DefaultJWTRSAKeyLength, cons.Length); err != nil {
+			return nil, err
+		}
+	}
+
+	return GeneratePrivateKey(cons.algorithm().keyAlgorithm(), length)
+}
+
+// newJWK returns the JWK of the given public key. Its key ID is the JWK thumbprint as defined by RFC 7638.
+func newJWK(publicKey crypto.PublicKey) (jwk, error) {
+	var key jwk
+	var thumbprint string
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		key = jwk{
+			Kty: "RSA",
+			Alg: string(JWTAlgorithmRS256),
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}
+		thumbprint = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, key.E, key.N)
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return jwk{}, fmt.Errorf("unsupported curve %s for signing keys", pub.Curve.Params().Name)
+		}
+		ecdhKey, err := pub.ECDH()
+		if err != nil {
+			return jwk{}, err
+		}
+		// the uncompressed point is 0x04 followed by the x and y coordinates
+		point := ecdhKey.Bytes()[1:]
+		key = jwk{
+			Kty: "EC",
+			Alg: string(JWTAlgorithmES256),
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(point[:len(point)/2]),
+			Y:   base64.RawURLEncoding.EncodeToString(point[len(point)/2:]),
+		}
+		thumbprint = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, key.Crv, key.X, key.Y)
+	case ed25519.PublicKey:
+		key = jwk{
+			Kty: "OKP",
+			Alg: string(JWTAlgorithmEdDSA),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}
+		thumbprint = fmt.Sprintf(`{"crv":"%s","kty":"OKP","x":"%s"}`, key.Crv, key.X)
+	default:
+		return jwk{}, fmt.Errorf("unsupported signing key type %T", publicKey)
+	}
+
+	digest := sha256.Sum256([]byte(thumbprint))
+	key.Use = "sig"
+	key.Kid = base64.RawURLEncoding.EncodeToString(digest[:])
+
+	return key, nil
+}
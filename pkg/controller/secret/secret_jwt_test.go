@@ -0,0 +1,234 @@
+package secret_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+// jwksKeyIDs returns the key IDs of the keys in the JWKS in data
+func jwksKeyIDs(t *testing.T, data map[string][]byte) []string {
+	keySet := struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+		} `json:"keys"`
+	}{}
+	require.NoError(t, json.Unmarshal(data[secret.FieldJWKS], &keySet))
+
+	kids := make([]string, 0, len(keySet.Keys))
+	for _, key := range keySet.Keys {
+		kids = append(kids, key.Kid)
+	}
+
+	return kids
+}
+
+// verifyJWTSigningKey verifies that the public key in data validates signatures of its private key
+func verifyJWTSigningKey(t *testing.T, data map[string][]byte) {
+	key, err := secret.ParsePrivateKeyPEM(data[secret.FieldJWTPrivateKey])
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(data[secret.FieldJWTPublicKey])
+	require.NotNil(t, block)
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	require.NoError(t, err)
+
+	message := []byte("header.payload")
+	digest := sha256.Sum256(message)
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		signature, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+		require.NoError(t, err)
+		require.NoError(t, rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature))
+	case *ecdsa.PublicKey:
+		signature, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+		require.NoError(t, err)
+		require.True(t, ecdsa.VerifyASN1(pub, digest[:], signature))
+	case ed25519.PublicKey:
+		signature, err := key.Sign(rand.Reader, message, crypto.Hash(0))
+		require.NoError(t, err)
+		require.True(t, ed25519.Verify(pub, message, signature))
+	default:
+		t.Fatalf("unexpected public key type %T", publicKey)
+	}
+
+	require.Equal(t, string(data[secret.FieldJWTKeyID]), jwksKeyIDs(t, data)[0])
+}
+
+func TestGenerateJWTData(t *testing.T) {
+	for _, algorithm := range []secret.JWTAlgorithm{secret.JWTAlgorithmRS256, secret.JWTAlgorithmES256, secret.JWTAlgorithmEdDSA} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			data := make(map[string][]byte)
+			cons := &secret.JWTConstraints{Algorithm: string(algorithm), KeepPrevious: true}
+
+			require.NoError(t, secret.GenerateJWTData(logf.Log, cons, false, data))
+			verifyJWTSigningKey(t, data)
+			kid := string(data[secret.FieldJWTKeyID])
+			require.Equal(t, []string{kid}, jwksKeyIDs(t, data))
+
+			// the key is kept, but missing public values are derived again
+			delete(data, secret.FieldJWTPublicKey)
+			delete(data, secret.FieldJWKS)
+			require.NoError(t, secret.GenerateJWTData(logf.Log, cons, false, data))
+			verifyJWTSigningKey(t, data)
+			require.Equal(t, kid, string(data[secret.FieldJWTKeyID]))
+
+			// the public key of the replaced key stays in the JWKS
+			require.NoError(t, secret.GenerateJWTData(logf.Log, cons, true, data))
+			verifyJWTSigningKey(t, data)
+			require.NotEqual(t, kid, string(data[secret.FieldJWTKeyID]))
+			require.Equal(t, []string{string(data[secret.FieldJWTKeyID]), kid}, jwksKeyIDs(t, data))
+
+			secret.PruneJWKS(data)
+			require.Equal(t, []string{string(data[secret.FieldJWTKeyID])}, jwksKeyIDs(t, data))
+		})
+	}
+}
+
+func TestGenerateJWTDataWithoutKeepPrevious(t *testing.T) {
+	data := make(map[string][]byte)
+	cons := &secret.JWTConstraints{Algorithm: string(secret.JWTAlgorithmEdDSA)}
+
+	require.NoError(t, secret.GenerateJWTData(logf.Log, cons, false, data))
+	require.NoError(t, secret.GenerateJWTData(logf.Log, cons, true, data))
+	require.Equal(t, []string{string(data[secret.FieldJWTKeyID])}, jwksKeyIDs(t, data))
+
+	require.Error(t, secret.GenerateJWTData(logf.Log, &secret.JWTConstraints{Algorithm: "HS256"}, false, data))
+}
+
+func newJWTTestSecret(extraAnnotations map[string]string) *corev1.Secret {
+	annotations := map[string]string{
+		secret.AnnotationSecretType:   string(secret.TypeJWT),
+		secret.AnnotationJWTAlgorithm: string(secret.JWTAlgorithmES256),
+	}
+	for key, value := range extraAnnotations {
+		annotations[key] = value
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSecretName(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+			Annotations: annotations,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{},
+	}
+}
+
+func TestJWTConstraintsValidate(t *testing.T) {
+	valid := []*secret.JWTConstraints{
+		{},
+		{Algorithm: string(secret.JWTAlgorithmRS256), Length: "3072"},
+		// the length is ignored for other algorithms
+		{Algorithm: string(secret.JWTAlgorithmES256), Length: "1024"},
+	}
+	for _, cons := range valid {
+		require.NoError(t, cons.Validate(), cons.Length)
+	}
+
+	invalid := []*secret.JWTConstraints{
+		{Algorithm: "HS256"},
+		{Length: "1024"},
+		{Algorithm: string(secret.JWTAlgorithmRS256), Length: "256b"},
+	}
+	for _, cons := range invalid {
+		require.Error(t, cons.Validate(), cons.Length)
+	}
+}
+
+func TestJWTSigningKeyIsRotated(t *testing.T) {
+	in := newJWTTestSecret(map[string]string{
+		secret.AnnotationSecretKeepPrevious: "1h",
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	verifyJWTSigningKey(t, out.Data)
+	kid := string(out.Data[secret.FieldJWTKeyID])
+
+	out.Annotations[secret.AnnotationSecretRegenerate] = "yes"
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), out))
+
+	doReconcile(t, out, false)
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	verifyJWTSigningKey(t, out.Data)
+	require.Equal(t, []string{string(out.Data[secret.FieldJWTKeyID]), kid}, jwksKeyIDs(t, out.Data))
+	require.Contains(t, out.Annotations, secret.AnnotationSecretPreviousExpiry)
+}
+
+func TestJWTSigningKeyIsRegeneratedForNewAlgorithm(t *testing.T) {
+	in := newJWTTestSecret(map[string]string{
+		secret.AnnotationSecretKeepPrevious: "1h",
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	kid := string(out.Data[secret.FieldJWTKeyID])
+
+	out.Annotations[secret.AnnotationJWTAlgorithm] = string(secret.JWTAlgorithmEdDSA)
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), out))
+
+	doReconcile(t, out, false)
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	verifyJWTSigningKey(t, out.Data)
+	key, err := secret.ParsePrivateKeyPEM(out.Data[secret.FieldJWTPrivateKey])
+	require.NoError(t, err)
+	require.IsType(t, ed25519.PrivateKey{}, key)
+	// the replaced key stays in the JWKS, so tokens signed with the previous algorithm can still be validated
+	require.Equal(t, []string{string(out.Data[secret.FieldJWTKeyID]), kid}, jwksKeyIDs(t, out.Data))
+	require.Contains(t, out.Annotations, secret.AnnotationSecretPreviousExpiry)
+}
+
+func TestPreviousJWKIsPruned(t *testing.T) {
+	data := make(map[string][]byte)
+	cons := &secret.JWTConstraints{Algorithm: string(secret.JWTAlgorithmES256), KeepPrevious: true}
+	require.NoError(t, secret.GenerateJWTData(logf.Log, cons, false, data))
+	require.NoError(t, secret.GenerateJWTData(logf.Log, cons, true, data))
+
+	generatedAt := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	in := newJWTTestSecret(map[string]string{
+		secret.AnnotationSecretKeepPrevious:    "1h",
+		secret.AnnotationSecretAutoGeneratedAt: generatedAt,
+		secret.AnnotationSecretPreviousExpiry:  time.Now().Add(-time.Hour).Format(time.RFC3339),
+	})
+	in.Data = data
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.Equal(t, []string{string(data[secret.FieldJWTKeyID])}, jwksKeyIDs(t, out.Data))
+	require.NotContains(t, out.Annotations, secret.AnnotationSecretPreviousExpiry)
+	// pruning must not delay rotations based on the generation time
+	require.Equal(t, generatedAt, out.Annotations[secret.AnnotationSecretAutoGeneratedAt])
+}
@@ -0,0 +1,269 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	FieldPGPPrivateKey  = "private.asc"
+	FieldPGPPublicKey   = "public.asc"
+	FieldPGPFingerprint = "fingerprint"
+)
+
+const (
+	DefaultPGPKeyAlgorithm = KeyAlgorithmEd25519
+	DefaultPGPRSAKeyLength = 4096
+)
+
+// pgpUserIDPattern matches user IDs of the form "Name (Comment) <email>", each part being optional
+var pgpUserIDPattern = regexp.MustCompile(`^([^()<>]*?)\s*(?:\(([^()<>]*)\))?\s*(?:<([^()<>]*)>)?$`)
+
+type PGPKeypairGenerator struct {
+	log    logr.Logger
+	client client.Client
+}
+
+// PGPKeypairConstraints configures the generation of OpenPGP keys
+type PGPKeypairConstraints struct {
+	// UserID identifies the owner of the key, e.g. "Backup Job <backup@example.com>"
+	UserID string
+	// Algorithm is either ed25519, which generates an ed25519 signing key with a cv25519 encryption subkey,
+	// or rsa
+	Algorithm string
+	// Length is the size of RSA keys in bits
+	Length string
+	// Expiry is the duration after which the key expires, keys do not expire if it is empty
+	Expiry string
+	// PassphraseField is the key holding the passphrase used to protect the private key. If the key is empty,
+	// a new passphrase will be generated along with the private key.
+	PassphraseField string
+	// Passphrase is used instead of PassphraseField, e.g. if the passphrase is read from another secret
+	Passphrase []byte
+}
+
+func (pg PGPKeypairGenerator) generateData(instance *corev1.Secret) (reconcile.Result, error) {
+	regenerate := instance.Annotations[AnnotationSecretRegenerate] != ""
+
+	if regenerate {
+		delete(instance.Annotations, AnnotationSecretRegenerate)
+	}
+
+	cons := &PGPKeypairConstraints{
+		UserID:          instance.Annotations[AnnotationPGPUserID],
+		Algorithm:       instance.Annotations[AnnotationKeyAlgorithm],
+		Length:          instance.Annotations[AnnotationSecretLength],
+		Expiry:          instance.Annotations[AnnotationPGPExpiry],
+		PassphraseField: instance.Annotations[AnnotationPassphraseField],
+	}
+	if cons.UserID == "" {
+		cons.UserID = instance.Name
+	}
+
+	if passphraseSecret, ok := instance.Annotations[AnnotationPassphraseSecret]; ok {
+		var err error
+		cons.Passphrase, err = GetPassphraseFromSecret(context.Background(), pg.client, instance.Namespace, passphraseSecret, cons.PassphraseField)
+		if err != nil {
+			pg.log.Error(err, "could not read passphrase from secret", "passphraseSecret", passphraseSecret)
+			return reconcile.Result{RequeueAfter: time.Second * 30}, err
+		}
+	}
+
+	if err := GeneratePGPKeypairData(pg.log, cons, regenerate, instance.Data); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// Validate checks whether an OpenPGP key can be generated with the given constraints
+func (cons *PGPKeypairConstraints) Validate() error {
+	if cons.UserID == "" {
+		return errors.New("user ID must be set")
+	}
+	if _, _, _, err := parsePGPUserID(cons.UserID); err != nil {
+		return err
+	}
+
+	switch algorithm := KeyAlgorithm(cons.Algorithm); algorithm {
+	case "", KeyAlgorithmEd25519, KeyAlgorithmRSA:
+	default:
+		return fmt.Errorf("%s is not a valid algorithm for OpenPGP keys, must be one of %s, %s", algorithm, KeyAlgorithmEd25519, KeyAlgorithmRSA)
+	}
+
+	if _, err := parsePGPExpiry(cons.Expiry); err != nil {
+		return err
+	}
+
+	_, err := ParseRSAKeyLength(DefaultPGPRSAKeyLength, cons.Length)
+
+	return err
+}
+
+// parsePGPUserID splits the given user ID into the name, comment and email it consists of
+func parsePGPUserID(userID string) (string, string, string, error) {
+	parts := pgpUserIDPattern.FindStringSubmatch(strings.TrimSpace(userID))
+	if parts == nil || strings.ContainsRune(userID, 0) {
+		return "", "", "", fmt.Errorf("user ID %s must have the form \"Name (Comment) <email>\"", userID)
+	}
+
+	return parts[1], parts[2], parts[3], nil
+}
+
+// parsePGPExpiry parses the lifetime of keys into seconds. Zero is returned if value is empty.
+func parsePGPExpiry(value string) (uint32, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	expiry, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if expiry < time.Second {
+		return 0, fmt.Errorf("key expiry %s must be at least one second", value)
+	}
+	if expiry.Seconds() > math.MaxUint32 {
+		return 0, fmt.Errorf("key expiry %s is too long", value)
+	}
+
+	return uint32(expiry.Seconds()), nil
+}
+
+// GeneratePGPKeypairData generates an OpenPGP key and writes its ASCII armored private and public key to data,
+// along with the fingerprint of the key. If a passphrase is configured, the private key is protected by it.
+// Existing keys are kept unless regenerate is set, a missing public key is derived from the existing private key.
+func GeneratePGPKeypairData(logger logr.Logger, cons *PGPKeypairConstraints, regenerate bool, data map[string][]byte) error {
+	if err := cons.Validate(); err != nil {
+		return err
+	}
+
+	privateKey := data[FieldPGPPrivateKey]
+	if len(privateKey) > 0 && !regenerate {
+		return CheckAndRegenPGPPublicKey(data, data[FieldPGPPublicKey], privateKey)
+	}
+
+	passphrase, err := getOrGeneratePassphrase(logger, cons.Passphrase, cons.PassphraseField, data)
+	if err != nil {
+		return err
+	}
+
+	entity, err := generatePGPEntity(cons)
+	if err != nil {
+		logger.Error(err, "could not generate OpenPGP key")
+		return err
+	}
+
+	if len(passphrase) > 0 {
+		if err = entity.EncryptPrivateKeys(passphrase, nil); err != nil {
+			return err
+		}
+	}
+
+	// the self-signatures were created along with the key, so they do not have to be signed again
+	privateKey, err = armorPGPEntity(openpgp.PrivateKeyType, func(w io.Writer) error {
+		return entity.SerializePrivateWithoutSigning(w, nil)
+	})
+	if err != nil {
+		return err
+	}
+	data[FieldPGPPrivateKey] = privateKey
+
+	return setPGPPublicData(data, entity)
+}
+
+// CheckAndRegenPGPPublicKey checks if the specified public key has length > 0 and derives it from the given ASCII
+// armored private key otherwise, along with the fingerprint of the key. The result is written into data
+func CheckAndRegenPGPPublicKey(data map[string][]byte, publicKey, privateKey []byte) error {
+	if len(publicKey) > 0 && len(data[FieldPGPFingerprint]) > 0 {
+		return nil
+	}
+
+	// the public key of encrypted private keys is not encrypted, so no passphrase is needed
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(privateKey))
+	if err != nil {
+		return fmt.Errorf("could not read private key: %w", err)
+	}
+	if len(entities) != 1 || entities[0].PrivateKey == nil {
+		return errors.New("private key must contain exactly one OpenPGP private key")
+	}
+
+	return setPGPPublicData(data, entities[0])
+}
+
+// generatePGPEntity generates a new OpenPGP key for the user ID, algorithm and expiry of cons
+func generatePGPEntity(cons *PGPKeypairConstraints) (*openpgp.Entity, error) {
+	name, comment, email, err := parsePGPUserID(cons.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime, err := parsePGPExpiry(cons.Expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &packet.Config{
+		Algorithm:       packet.PubKeyAlgoEdDSA,
+		Curve:           packet.Curve25519,
+		KeyLifetimeSecs: lifetime,
+	}
+
+	if KeyAlgorithm(cons.Algorithm) == KeyAlgorithmRSA {
+		length, err := ParseRSAKeyLength(DefaultPGPRSAKeyLength, cons.Length)
+		if err != nil {
+			return nil, err
+		}
+		config.Algorithm = packet.PubKeyAlgoRSA
+		config.RSABits = length
+	}
+
+	return openpgp.NewEntity(name, comment, email, config)
+}
+
+// setPGPPublicData writes the ASCII armored public key and the fingerprint of entity to data
+func setPGPPublicData(data map[string][]byte, entity *openpgp.Entity) error {
+	publicKey, err := armorPGPEntity(openpgp.PublicKeyType, entity.Serialize)
+	if err != nil {
+		return err
+	}
+
+	data[FieldPGPPublicKey] = publicKey
+	data[FieldPGPFingerprint] = []byte(strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint)))
+
+	return nil
+}
+
+// armorPGPEntity returns the ASCII armored block of the given type written by serialize
+func armorPGPEntity(blockType string, serialize func(w io.Writer) error) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, blockType, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = serialize(w); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,231 @@
+package secret_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/pgpkeypair"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+// verifyPGPKeypair verifies that the public key and fingerprint in data belong to its private key, which can be
+// decrypted with passphrase, and returns the key
+func verifyPGPKeypair(t *testing.T, data map[string][]byte, passphrase []byte) *openpgp.Entity {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data[secret.FieldPGPPrivateKey]))
+	require.NoError(t, err)
+	require.Len(t, entities, 1)
+	entity := entities[0]
+
+	require.Equal(t, len(passphrase) > 0, entity.PrivateKey.Encrypted)
+	if len(passphrase) > 0 {
+		require.NoError(t, entity.DecryptPrivateKeys(passphrase))
+	}
+
+	publicKeys, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data[secret.FieldPGPPublicKey]))
+	require.NoError(t, err)
+	require.Len(t, publicKeys, 1)
+	require.Nil(t, publicKeys[0].PrivateKey)
+	require.Equal(t, entity.PrimaryKey.Fingerprint, publicKeys[0].PrimaryKey.Fingerprint)
+	require.Equal(t, strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint)), string(data[secret.FieldPGPFingerprint]))
+
+	return entity
+}
+
+func TestGeneratePGPKeypairData(t *testing.T) {
+	data := make(map[string][]byte)
+	cons := &secret.PGPKeypairConstraints{
+		UserID:          "Backup Job (dumps) <backup@example.com>",
+		Expiry:          "24h",
+		PassphraseField: secret.SecretFieldPassphrase,
+	}
+
+	require.NoError(t, secret.GeneratePGPKeypairData(logf.Log, cons, false, data))
+	passphrase := data[secret.SecretFieldPassphrase]
+	require.NotEmpty(t, passphrase)
+	entity := verifyPGPKeypair(t, data, passphrase)
+	require.Contains(t, entity.Identities, cons.UserID)
+	require.Len(t, entity.Subkeys, 1)
+	require.EqualValues(t, 24*60*60, *entity.PrimaryIdentity().SelfSignature.KeyLifetimeSecs)
+
+	// the key can be used to encrypt backups
+	encrypted := &bytes.Buffer{}
+	w, err := openpgp.Encrypt(encrypted, openpgp.EntityList{entity}, nil, nil, nil)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("dump"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	_, err = openpgp.ReadMessage(encrypted, openpgp.EntityList{entity}, nil, nil)
+	require.NoError(t, err)
+
+	privateKey := string(data[secret.FieldPGPPrivateKey])
+	publicKey := string(data[secret.FieldPGPPublicKey])
+
+	// a missing public key is derived from the existing private key
+	delete(data, secret.FieldPGPPublicKey)
+	delete(data, secret.FieldPGPFingerprint)
+	require.NoError(t, secret.GeneratePGPKeypairData(logf.Log, cons, false, data))
+	require.Equal(t, privateKey, string(data[secret.FieldPGPPrivateKey]))
+	require.Equal(t, publicKey, string(data[secret.FieldPGPPublicKey]))
+	verifyPGPKeypair(t, data, passphrase)
+
+	require.NoError(t, secret.GeneratePGPKeypairData(logf.Log, cons, true, data))
+	verifyPGPKeypair(t, data, passphrase)
+	require.NotEqual(t, privateKey, string(data[secret.FieldPGPPrivateKey]))
+
+	data = make(map[string][]byte)
+	cons = &secret.PGPKeypairConstraints{UserID: "rsa@example.com", Algorithm: string(secret.KeyAlgorithmRSA), Length: "2048"}
+	require.NoError(t, secret.GeneratePGPKeypairData(logf.Log, cons, false, data))
+	entity = verifyPGPKeypair(t, data, nil)
+	bitLength, err := entity.PrimaryKey.BitLength()
+	require.NoError(t, err)
+	require.EqualValues(t, 2048, bitLength)
+	require.NotContains(t, data, secret.SecretFieldPassphrase)
+
+	data = map[string][]byte{secret.FieldPGPPrivateKey: []byte("invalid")}
+	require.Error(t, secret.GeneratePGPKeypairData(logf.Log, cons, false, data))
+}
+
+func TestPGPKeypairConstraintsValidate(t *testing.T) {
+	valid := []*secret.PGPKeypairConstraints{
+		{UserID: "Backup Job"},
+		{UserID: "Backup Job <backup@example.com>"},
+		{UserID: "<backup@example.com>", Algorithm: string(secret.KeyAlgorithmEd25519), Expiry: "8760h"},
+		{UserID: "Backup Job (dumps) <backup@example.com>", Algorithm: string(secret.KeyAlgorithmRSA), Length: "3072"},
+	}
+	for _, cons := range valid {
+		require.NoError(t, cons.Validate(), cons.UserID)
+	}
+
+	invalid := []*secret.PGPKeypairConstraints{
+		{},
+		{UserID: "Backup Job <backup@example.com"},
+		{UserID: "Backup (Job"},
+		{UserID: "Backup Job", Algorithm: string(secret.KeyAlgorithmECDSAP256)},
+		{UserID: "Backup Job", Expiry: "-1h"},
+		{UserID: "Backup Job", Expiry: "1y"},
+		{UserID: "Backup Job", Length: "long"},
+		{UserID: "Backup Job", Algorithm: string(secret.KeyAlgorithmRSA), Length: "1024"},
+		{UserID: "Backup Job", Algorithm: string(secret.KeyAlgorithmRSA), Length: "512b"},
+	}
+	for _, cons := range invalid {
+		require.Error(t, cons.Validate(), cons.UserID)
+	}
+}
+
+func TestPGPKeypairIsGenerated(t *testing.T) {
+	in := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSecretName(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+			Annotations: map[string]string{
+				secret.AnnotationSecretType:      string(secret.TypePGPKeypair),
+				secret.AnnotationPGPUserID:       "Backup Job <backup@example.com>",
+				secret.AnnotationPassphraseField: secret.SecretFieldPassphrase,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{},
+	}
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	entity := verifyPGPKeypair(t, out.Data, out.Data[secret.SecretFieldPassphrase])
+	require.Contains(t, entity.Identities, "Backup Job <backup@example.com>")
+}
+
+func newPGPKeyPairTestCR(spec v1alpha1.PGPKeyPairSpec) *v1alpha1.PGPKeyPair {
+	return &v1alpha1.PGPKeyPair{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiVersion,
+			Kind:       pgpkeypair.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      uuid.New().String(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+		},
+		Spec: spec,
+	}
+}
+
+func doReconcilePGPKeyPairController(t *testing.T, cr *v1alpha1.PGPKeyPair, isErr bool) {
+	rec := pgpkeypair.NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}}
+
+	res, err := rec.Reconcile(req)
+
+	if isErr {
+		require.Error(t, err)
+	} else {
+		require.NoError(t, err)
+	}
+	require.False(t, res.Requeue)
+}
+
+func TestControllerGeneratePGPKeyPair(t *testing.T) {
+	in := newPGPKeyPairTestCR(v1alpha1.PGPKeyPairSpec{Passphrase: &v1alpha1.Passphrase{}})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcilePGPKeyPairController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	entity := verifyPGPKeypair(t, out.Data, out.Data[secret.SecretFieldPassphrase])
+	// the user ID defaults to the name of the cr
+	require.Contains(t, entity.Identities, in.Name)
+	privateKey := string(out.Data[secret.FieldPGPPrivateKey])
+
+	// the public key is restored if it is removed from the Secret
+	delete(out.Data, secret.FieldPGPPublicKey)
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), out))
+
+	doReconcilePGPKeyPairController(t, in, false)
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.Equal(t, privateKey, string(out.Data[secret.FieldPGPPrivateKey]))
+	verifyPGPKeypair(t, out.Data, out.Data[secret.SecretFieldPassphrase])
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestPGPKeyPairValidateSpec(t *testing.T) {
+	in := newPGPKeyPairTestCR(v1alpha1.PGPKeyPairSpec{UserID: "Backup Job <backup@example.com"})
+	require.Error(t, pgpkeypair.ValidateSpec(in))
+
+	in.Spec.UserID = ""
+	require.NoError(t, pgpkeypair.ValidateSpec(in))
+
+	in.Spec.Algorithm = string(secret.KeyAlgorithmECDSAP384)
+	require.Error(t, pgpkeypair.ValidateSpec(in))
+
+	in.Spec.Algorithm = string(secret.KeyAlgorithmRSA)
+	in.Spec.Length = "1024"
+	require.Error(t, pgpkeypair.ValidateSpec(in))
+
+	in.Spec.Length = "512b"
+	require.Error(t, pgpkeypair.ValidateSpec(in))
+
+	in.Spec.Length = "3072"
+	require.NoError(t, pgpkeypair.ValidateSpec(in))
+}
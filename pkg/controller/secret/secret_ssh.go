@@ -2,26 +2,45 @@ package secret
 
 import (
 	"bytes"
-	"crypto/rand"
+	"context"
+	"crypto"
 	"crypto/rsa"
-	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/go-logr/logr"
 	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 const (
 	SecretFieldPublicKey  = "ssh-publickey"
 	SecretFieldPrivateKey = "ssh-privatekey"
+	SecretFieldPassphrase = "passphrase"
 )
 
+// SSHKeypairFields are the keys of the key pair, which change whenever it is regenerated
+var SSHKeypairFields = []string{SecretFieldPrivateKey, SecretFieldPublicKey}
+
 type SSHKeypairGenerator struct {
-	log logr.Logger
+	log    logr.Logger
+	client client.Client
+}
+
+type SSHKeypairConstraints struct {
+	Length    string
+	Algorithm string
+	Format    string
+	// PassphraseField is the key holding the passphrase used to protect the private key. If the key is empty,
+	// a new passphrase will be generated along with the private key.
+	PassphraseField string
+	// Passphrase is used instead of PassphraseField, e.g. if the passphrase is read from another secret
+	Passphrase []byte
 }
 
 func (sg SSHKeypairGenerator) generateData(instance *corev1.Secret) (reconcile.Result, error) {
@@ -31,23 +50,68 @@ func (sg SSHKeypairGenerator) generateData(instance *corev1.Secret) (reconcile.R
 		delete(instance.Annotations, AnnotationSecretRegenerate)
 	}
 
-	length, err := GetLengthFromAnnotation(SSHKeyLength(), instance.Annotations)
+	cons := &SSHKeypairConstraints{
+		Length:          instance.Annotations[AnnotationSecretLength],
+		Algorithm:       instance.Annotations[AnnotationKeyAlgorithm],
+		Format:          instance.Annotations[AnnotationPrivateKeyFormat],
+		PassphraseField: instance.Annotations[AnnotationPassphraseField],
+	}
+
+	if passphraseSecret, ok := instance.Annotations[AnnotationPassphraseSecret]; ok {
+		var err error
+		cons.Passphrase, err = GetPassphraseFromSecret(context.Background(), sg.client, instance.Namespace, passphraseSecret, cons.PassphraseField)
+		if err != nil {
+			sg.log.Error(err, "could not read passphrase from secret", "passphraseSecret", passphraseSecret)
+			return reconcile.Result{RequeueAfter: time.Second * 30}, err
+		}
+	}
+
+	gracePeriod, err := ParseGracePeriod(instance.Annotations[AnnotationSecretKeepPrevious])
 	if err != nil {
+		sg.log.Error(err, "could not parse grace period for previous values")
 		return reconcile.Result{}, err
 	}
 
-	err = GenerateSSHKeypairData(sg.log, length, regenerate, instance.Data)
-	if err != nil {
+	PrunePreviousValues(instance, SSHKeypairFields)
+
+	keptPrevious := regenerate && gracePeriod > 0 && KeepPreviousValues(instance.Data, SSHKeypairFields...)
+
+	if err := GenerateSSHKeypairData(sg.log, cons, regenerate, instance.Data); err != nil {
 		return reconcile.Result{RequeueAfter: time.Second * 30}, err
 	}
 
-	return reconcile.Result{}, nil
+	if keptPrevious {
+		SetPreviousValuesExpiry(instance, gracePeriod)
+	}
+
+	return reconcile.Result{RequeueAfter: PreviousValuesExpireIn(instance)}, nil
+}
+
+// GetPassphraseFromSecret reads the passphrase stored under field in the given secret. If field is empty,
+// the passphrase is expected in the "passphrase" key.
+func GetPassphraseFromSecret(ctx context.Context, c client.Reader, namespace, name, field string) ([]byte, error) {
+	if field == "" {
+		field = SecretFieldPassphrase
+	}
+
+	passphraseSecret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, passphraseSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase := passphraseSecret.Data[field]
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("secret %s does not contain a passphrase in key %s", name, field)
+	}
+
+	return passphrase, nil
 }
 
-// generates ssh private and public key of given length
+// generates ssh private and public key of given algorithm and length
 // and writes the result to data. The public key is in authorized-keys format,
 // the private key is PEM encoded
-func GenerateSSHKeypairData(logger logr.Logger, length string, regenerate bool, data map[string][]byte) error {
+func GenerateSSHKeypairData(logger logr.Logger, cons *SSHKeypairConstraints, regenerate bool, data map[string][]byte) error {
 	privateKey := data[SecretFieldPrivateKey]
 	publicKey := data[SecretFieldPublicKey]
 
@@ -55,33 +119,124 @@ func GenerateSSHKeypairData(logger logr.Logger, length string, regenerate bool,
 		return CheckAndRegenPublicKey(data, publicKey, privateKey)
 	}
 
-	key, err := generateNewPrivateKey(length, logger)
+	passphrase, err := getOrGeneratePassphrase(logger, cons.Passphrase, cons.PassphraseField, data)
 	if err != nil {
 		return err
 	}
 
-	return generateKeysHelper(key, data)
+	key, err := generateNewPrivateKey(cons, logger)
+	if err != nil {
+		return err
+	}
+
+	return generateKeysHelper(key, PrivateKeyFormat(cons.Format), passphrase, data)
 }
 
-// generateNewPrivateKey parses the given length and generates a matching private key
-func generateNewPrivateKey(length string, logger logr.Logger) (*rsa.PrivateKey, error) {
-	// check for existing values, if regeneration isn't forced
+// getOrGeneratePassphrase returns the passphrase the private key should be protected with, if any. The given
+// passphrase is preferred over the one stored under field in the secret itself, which is generated if empty.
+func getOrGeneratePassphrase(logger logr.Logger, passphrase []byte, field string, data map[string][]byte) ([]byte, error) {
+	if len(passphrase) > 0 {
+		return passphrase, nil
+	}
+
+	if field == "" {
+		return nil, nil
+	}
 
-	parsedLen, _, err := ParseByteLength(DefaultLength(), length)
+	if stored := data[field]; len(stored) > 0 {
+		return stored, nil
+	}
+
+	passphrase, err := GenerateRandomString(DefaultLength(), DefaultEncoding(), false)
 	if err != nil {
-		logger.Error(err, "could not parse length for new random string")
+		logger.Error(err, "could not generate passphrase")
 
 		return nil, err
 	}
-	return rsa.GenerateKey(rand.Reader, parsedLen)
+	data[field] = passphrase
+
+	return passphrase, nil
 }
 
-// generateKeysHelper generates the public key from the given private key and stores the result in data
-func generateKeysHelper(key *rsa.PrivateKey, data map[string][]byte) error {
+// Validate checks whether a key pair can be generated with the given constraints
+func (cons *SSHKeypairConstraints) Validate() error {
+	algorithm := cons.keyAlgorithm()
+	if err := algorithm.Validate(); err != nil {
+		return err
+	}
+
+	if format := PrivateKeyFormat(cons.Format); format != "" {
+		if err := format.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if algorithm != KeyAlgorithmRSA {
+		return nil
+	}
+
+	_, err := cons.rsaKeyLength()
+
+	return err
+}
+
+// keyAlgorithm returns the algorithm of cons, which defaults to the configured SSH key algorithm
+func (cons *SSHKeypairConstraints) keyAlgorithm() KeyAlgorithm {
+	if cons.Algorithm == "" {
+		return SSHKeyAlgorithm()
+	}
+	return KeyAlgorithm(cons.Algorithm)
+}
+
+// rsaKeyLength parses the size of RSA keys in bits, which defaults to the configured SSH key length. The length
+// is ignored for all other algorithms. Keys shorter than MinRSAKeyLength are still accepted for SSH key pairs to
+// keep existing Secrets working.
+func (cons *SSHKeypairConstraints) rsaKeyLength() (int, error) {
+	length, _, err := ParseByteLength(SSHKeyLength(), cons.Length)
+
+	return length, err
+}
+
+// generateNewPrivateKey parses the given constraints and generates a matching private key
+func generateNewPrivateKey(cons *SSHKeypairConstraints, logger logr.Logger) (crypto.Signer, error) {
+	algorithm := cons.keyAlgorithm()
+	if err := algorithm.Validate(); err != nil {
+		logger.Error(err, "could not determine algorithm for new ssh key pair")
+
+		return nil, err
+	}
+
+	if format := PrivateKeyFormat(cons.Format); format != "" {
+		if err := format.Validate(); err != nil {
+			logger.Error(err, "could not determine format for new ssh key pair")
+
+			return nil, err
+		}
+	}
+
+	var length int
+	if algorithm == KeyAlgorithmRSA {
+		var err error
+		if length, err = cons.rsaKeyLength(); err != nil {
+			logger.Error(err, "could not determine length for new ssh key pair")
+
+			return nil, err
+		}
+	}
+
+	return GeneratePrivateKey(algorithm, length)
+}
+
+// generateKeysHelper generates the public key from the given private key and stores the result in data.
+// The private key is encoded using the given format and protected by passphrase, if it is not empty.
+func generateKeysHelper(key crypto.Signer, format PrivateKeyFormat, passphrase []byte, data map[string][]byte) error {
+	block, err := MarshalPrivateKeyPEM(key, format, passphrase)
+	if err != nil {
+		return err
+	}
+
 	privateKeyBytes := &bytes.Buffer{}
-	err := pem.Encode(
-		privateKeyBytes,
-		&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	err = pem.Encode(privateKeyBytes, block)
 	if err != nil {
 		return err
 	}
@@ -98,21 +253,22 @@ func generateKeysHelper(key *rsa.PrivateKey, data map[string][]byte) error {
 	return nil
 }
 
+// PrivateKeyFromPEM parses a PEM encoded RSA private key. Use ParsePrivateKeyPEM for keys of other algorithms.
 func PrivateKeyFromPEM(pemKey []byte) (*rsa.PrivateKey, error) {
-	b, _ := pem.Decode(pemKey)
-	if b == nil {
-		return nil, errors.New("failed to parse private Key PEM block")
-	}
-
-	privateKey, err := x509.ParsePKCS1PrivateKey(b.Bytes)
+	key, err := ParsePrivateKeyPEM(pemKey)
 	if err != nil {
 		return nil, err
 	}
+
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected RSA private key, got %T", key)
+	}
 	return privateKey, nil
 }
 
-func SSHPublicKeyForPrivateKey(privateKey *rsa.PrivateKey) ([]byte, error) {
-	publicKey, err := ssh.NewPublicKey(&privateKey.PublicKey)
+func SSHPublicKeyForPrivateKey(privateKey crypto.Signer) ([]byte, error) {
+	publicKey, err := ssh.NewPublicKey(privateKey.Public())
 	if err != nil {
 		return nil, err
 	}
@@ -128,11 +284,17 @@ func CheckAndRegenPublicKey(data map[string][]byte, publicKey, privateKey []byte
 	}
 
 	// restore public key if private key exists
-	rsaKey, err := PrivateKeyFromPEM(privateKey)
+	key, err := ParsePrivateKeyPEM(privateKey)
+	var passphraseErr *ssh.PassphraseMissingError
+	if errors.As(err, &passphraseErr) && passphraseErr.PublicKey != nil {
+		// encrypted keys in the OpenSSH format contain the unencrypted public key
+		data[SecretFieldPublicKey] = ssh.MarshalAuthorizedKey(passphraseErr.PublicKey)
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	publicKey, err = SSHPublicKeyForPrivateKey(rsaKey)
+	publicKey, err = SSHPublicKeyForPrivateKey(key)
 	if err != nil {
 		return err
 	}
@@ -3,10 +3,15 @@ package secret_test
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/pem"
 	"github.com/go-logr/logr"
 	"github.com/imdario/mergo"
 
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -44,7 +49,10 @@ func newSSHKeypairTestSecret(t *testing.T, extraAnnotations map[string]string, i
 	var log logr.Logger
 
 	if initialized {
-		err := secret.GenerateSSHKeypairData(log, strconv.Itoa(secret.SSHKeyLength()), true, s.Data)
+		err := secret.GenerateSSHKeypairData(log, &secret.SSHKeypairConstraints{
+			Length:    strconv.Itoa(secret.SSHKeyLength()),
+			Algorithm: annotations[secret.AnnotationKeyAlgorithm],
+		}, true, s.Data)
 		if err != nil {
 			t.Error(err, "could not generate new ssh keypair")
 		}
@@ -71,14 +79,16 @@ func verifySSHKeypairSecret(t *testing.T, in, out *corev1.Secret) {
 		t.Errorf("publicKey(%d) or privateKey(%d) have invalid length", len(publicKey), len(privateKey))
 	}
 
-	key, err := secret.PrivateKeyFromPEM(privateKey)
+	key, err := secret.ParsePrivateKeyPEM(privateKey)
 	if err != nil {
 		t.Error(err, "generated private key could not be parsed")
 	}
 
-	err = key.Validate()
-	if err != nil {
-		t.Error(err, "key validation failed")
+	if rsaKey, ok := key.(*rsa.PrivateKey); ok {
+		err = rsaKey.Validate()
+		if err != nil {
+			t.Error(err, "key validation failed")
+		}
 	}
 
 	pub, err := secret.SSHPublicKeyForPrivateKey(key)
@@ -219,3 +229,253 @@ func TestSSHKeypairLengthDefault(t *testing.T) {
 		t.Error("wrong generated secret length")
 	}
 }
+
+func TestSSHKeypairEd25519Annotation(t *testing.T) {
+	in := newSSHKeypairTestSecret(t, map[string]string{
+		secret.AnnotationKeyAlgorithm: string(secret.KeyAlgorithmEd25519),
+	}, false)
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+	verifySSHKeypairSecret(t, in, out)
+
+	key, err := secret.ParsePrivateKeyPEM(out.Data[secret.SecretFieldPrivateKey])
+	require.NoError(t, err)
+
+	if _, ok := key.(ed25519.PrivateKey); !ok {
+		t.Errorf("generated private key has wrong type %T", key)
+	}
+
+	if !bytes.HasPrefix(out.Data[secret.SecretFieldPublicKey], []byte("ssh-ed25519 ")) {
+		t.Error("generated public key is not an ed25519 key")
+	}
+}
+
+func TestSSHKeypairECDSAAnnotation(t *testing.T) {
+	curves := map[secret.KeyAlgorithm]int{
+		secret.KeyAlgorithmECDSAP256: 256,
+		secret.KeyAlgorithmECDSAP384: 384,
+		secret.KeyAlgorithmECDSAP521: 521,
+	}
+
+	for algorithm, bitSize := range curves {
+		in := newSSHKeypairTestSecret(t, map[string]string{
+			secret.AnnotationKeyAlgorithm: string(algorithm),
+		}, false)
+		require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+		doReconcile(t, in, false)
+
+		out := &corev1.Secret{}
+		require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+			Name:      in.Name,
+			Namespace: in.Namespace}, out))
+		verifySSHKeypairSecret(t, in, out)
+
+		key, err := secret.ParsePrivateKeyPEM(out.Data[secret.SecretFieldPrivateKey])
+		require.NoError(t, err)
+
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			t.Errorf("generated private key has wrong type %T", key)
+			continue
+		}
+
+		if ecKey.Curve.Params().BitSize != bitSize {
+			t.Errorf("generated key uses wrong curve %s", ecKey.Curve.Params().Name)
+		}
+	}
+}
+
+func TestSSHKeypairInvalidAlgorithm(t *testing.T) {
+	in := newSSHKeypairTestSecret(t, map[string]string{
+		secret.AnnotationKeyAlgorithm: "dsa",
+	}, false)
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, true)
+}
+
+func TestSSHKeypairConstraintsValidate(t *testing.T) {
+	valid := []*secret.SSHKeypairConstraints{
+		{},
+		{Algorithm: string(secret.KeyAlgorithmRSA), Length: "4096"},
+		// short RSA keys are accepted for backwards compatibility
+		{Algorithm: string(secret.KeyAlgorithmRSA), Length: "40"},
+		// the length is only taken into account for RSA keys
+		{Algorithm: string(secret.KeyAlgorithmEd25519), Length: "40"},
+		{Algorithm: string(secret.KeyAlgorithmECDSAP256), Length: "forty"},
+	}
+	for _, cons := range valid {
+		require.NoError(t, cons.Validate(), cons.Length)
+	}
+
+	invalid := []*secret.SSHKeypairConstraints{
+		{Algorithm: string(secret.KeyAlgorithmRSA), Length: "forty"},
+		{Length: "forty"},
+		{Algorithm: "dsa"},
+		{Format: "ppk"},
+	}
+	for _, cons := range invalid {
+		require.Error(t, cons.Validate(), cons.Length)
+	}
+}
+
+func TestSSHKeypairRegenPublicKeyEd25519(t *testing.T) {
+	in := newSSHKeypairTestSecret(t, map[string]string{
+		secret.AnnotationKeyAlgorithm: string(secret.KeyAlgorithmEd25519),
+	}, true)
+	publicKey := in.Data[secret.SecretFieldPublicKey]
+	in.Data[secret.SecretFieldPublicKey] = []byte{}
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+	verifySSHKeypairSecret(t, in, out)
+
+	if !bytes.Equal(publicKey, out.Data[secret.SecretFieldPublicKey]) {
+		t.Error("restored publicKey doesn't match original public key")
+	}
+}
+
+func TestSSHKeypairPrivateKeyFormatAnnotation(t *testing.T) {
+	formats := map[secret.PrivateKeyFormat]string{
+		secret.PrivateKeyFormatPKCS1:   "RSA PRIVATE KEY",
+		secret.PrivateKeyFormatPKCS8:   "PRIVATE KEY",
+		secret.PrivateKeyFormatOpenSSH: "OPENSSH PRIVATE KEY",
+	}
+
+	for format, blockType := range formats {
+		in := newSSHKeypairTestSecret(t, map[string]string{
+			secret.AnnotationPrivateKeyFormat: string(format),
+		}, false)
+		require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+		doReconcile(t, in, false)
+
+		out := &corev1.Secret{}
+		require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+			Name:      in.Name,
+			Namespace: in.Namespace}, out))
+		verifySSHKeypairSecret(t, in, out)
+
+		block, _ := pem.Decode(out.Data[secret.SecretFieldPrivateKey])
+		require.NotNil(t, block)
+
+		if block.Type != blockType {
+			t.Errorf("private key has wrong PEM block type %s, expected %s", block.Type, blockType)
+		}
+	}
+}
+
+func TestSSHKeypairPassphraseFieldAnnotation(t *testing.T) {
+	in := newSSHKeypairTestSecret(t, map[string]string{
+		secret.AnnotationKeyAlgorithm:    string(secret.KeyAlgorithmEd25519),
+		secret.AnnotationPassphraseField: "passphrase",
+	}, false)
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	passphrase := out.Data["passphrase"]
+	if len(passphrase) != secret.DefaultLength() {
+		t.Errorf("generated passphrase has wrong length %d", len(passphrase))
+	}
+
+	verifyEncryptedSSHKeypair(t, out, passphrase)
+}
+
+func TestSSHKeypairPassphraseSecretAnnotation(t *testing.T) {
+	passphraseSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSecretName(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"key-passphrase": []byte("correct horse battery staple"),
+		},
+	}
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), passphraseSecret))
+
+	in := newSSHKeypairTestSecret(t, map[string]string{
+		secret.AnnotationPassphraseSecret: passphraseSecret.Name,
+		secret.AnnotationPassphraseField:  "key-passphrase",
+	}, false)
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	if _, ok := out.Data["key-passphrase"]; ok {
+		t.Error("passphrase from other secret has been copied into generated secret")
+	}
+
+	verifyEncryptedSSHKeypair(t, out, passphraseSecret.Data["key-passphrase"])
+}
+
+func TestSSHKeypairPassphraseSecretMissing(t *testing.T) {
+	in := newSSHKeypairTestSecret(t, map[string]string{
+		secret.AnnotationPassphraseSecret: getSecretName(),
+	}, false)
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, true)
+}
+
+// verifyEncryptedSSHKeypair checks that the private key is protected by passphrase and matches the public key
+func verifyEncryptedSSHKeypair(t *testing.T, out *corev1.Secret, passphrase []byte) {
+	privateKey := out.Data[secret.SecretFieldPrivateKey]
+
+	if _, err := ssh.ParseRawPrivateKey(privateKey); err == nil {
+		t.Error("private key is not protected by a passphrase")
+	}
+
+	key, err := ssh.ParsePrivateKeyWithPassphrase(privateKey, passphrase)
+	require.NoError(t, err)
+
+	if !bytes.Equal(out.Data[secret.SecretFieldPublicKey], ssh.MarshalAuthorizedKey(key.PublicKey())) {
+		t.Error("publicKey doesn't match private key")
+	}
+}
+
+func TestSSHKeypairKeepPrevious(t *testing.T) {
+	in := newSSHKeypairTestSecret(t, map[string]string{
+		secret.AnnotationSecretRegenerate:   "true",
+		secret.AnnotationSecretKeepPrevious: "1h",
+	}, true)
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+	verifySSHKeypairRegen(t, in, out, true)
+
+	require.Equal(t, in.Data[secret.SecretFieldPrivateKey], out.Data[secret.SecretFieldPrivateKey+secret.SecretFieldPreviousSuffix])
+	require.Equal(t, in.Data[secret.SecretFieldPublicKey], out.Data[secret.SecretFieldPublicKey+secret.SecretFieldPreviousSuffix])
+	require.Contains(t, out.Annotations, secret.AnnotationSecretPreviousExpiry)
+}
@@ -30,7 +30,7 @@ func (pg StringGenerator) generateData(instance *corev1.Secret) (reconcile.Resul
 
 	genKeys := strings.Split(toGenerate, ",")
 
-	if err := ensureUniqueness(genKeys); err != nil {
+	if err := EnsureUniqueness(genKeys); err != nil {
 		return reconcile.Result{}, err
 	}
 
@@ -47,7 +47,22 @@ func (pg StringGenerator) generateRandomSecret(conf secretConfig) error {
 	if err != nil {
 		return err
 	}
-	value, err := GenerateRandomString(length, encoding, isByteLength)
+	var value []byte
+	if encoding == EncodingCharset {
+		cons, consErr := GetCharsetConstraintsFromAnnotations(instance.Annotations)
+		if consErr != nil {
+			return consErr
+		}
+		value, err = GenerateCharsetString(length, cons)
+	} else if encoding == EncodingPassphrase {
+		cons, consErr := GetPassphraseConstraintsFromAnnotations(instance.Annotations)
+		if consErr != nil {
+			return consErr
+		}
+		value, err = GeneratePassphrase(cons)
+	} else {
+		value, err = GenerateRandomString(length, encoding, isByteLength)
+	}
 	if err != nil {
 		return err
 	}
@@ -58,6 +73,18 @@ func (pg StringGenerator) generateRandomSecret(conf secretConfig) error {
 	return nil
 }
 
+// encodings are the encodings random strings can be generated with
+var encodings = []string{"base64", "base64url", "base32", "hex", "raw", EncodingCharset, EncodingPassphrase}
+
+// ValidateEncoding checks whether random strings can be generated with the given encoding.
+// An empty encoding selects the default encoding.
+func ValidateEncoding(encoding string) error {
+	if encoding == "" || contains(encodings, encoding) {
+		return nil
+	}
+	return fmt.Errorf("%s is not a valid encoding, must be one of %s", encoding, strings.Join(encodings, ", "))
+}
+
 // GenerateRandomString generates a random string of given length and with given encoding.
 // If lenBytes is true, resultring string will not be trimmed
 func GenerateRandomString(length int, encoding string, lenBytes bool) ([]byte, error) {
@@ -73,6 +100,12 @@ func GenerateRandomString(length int, encoding string, lenBytes bool) ([]byte, e
 		encodedString = base64.URLEncoding.EncodeToString(b)
 	case "raw":
 		return b, nil
+	case EncodingCharset:
+		// charset strings are not encoded bytes, so their length is always the number of characters
+		return GenerateCharsetString(length, &CharsetConstraints{})
+	case EncodingPassphrase:
+		// passphrases consist of words, so the length is ignored
+		return GeneratePassphrase(&PassphraseConstraints{})
 	case "base32":
 		encodedString = base32.StdEncoding.EncodeToString(b)
 	case "hex":
@@ -88,8 +121,8 @@ func GenerateRandomString(length int, encoding string, lenBytes bool) ([]byte, e
 	return []byte(encodedString[0:length]), nil
 }
 
-// ensure elements in input array are unique
-func ensureUniqueness(a []string) error {
+// EnsureUniqueness ensures elements in input array are unique
+func EnsureUniqueness(a []string) error {
 	set := map[string]bool{}
 	for _, e := range a {
 		if set[e] {
@@ -126,6 +159,29 @@ func (pg StringGenerator) regenerateKeysWhereRequired(instance *corev1.Secret, g
 		}
 	}
 
+	rotateAfter, err := getRotateAfterFromAnnotation(instance.Annotations)
+	if err != nil {
+		pg.log.Error(err, "could not parse rotation interval")
+		return reconcile.Result{}, err
+	}
+
+	var nextRotation time.Time
+	if rotateAfter > 0 {
+		nextRotation = nextRotationTime(pg.log, instance, rotateAfter)
+		if !time.Now().Before(nextRotation) {
+			pg.log.Info("rotation interval has passed, rotating all keys", "rotateAfter", rotateAfter)
+			regenKeys = genKeys // rotate all keys
+		}
+	}
+
+	gracePeriod, err := ParseGracePeriod(instance.Annotations[AnnotationSecretKeepPrevious])
+	if err != nil {
+		pg.log.Error(err, "could not parse grace period for previous values")
+		return reconcile.Result{}, err
+	}
+
+	PrunePreviousValues(instance, genKeys)
+
 	length, err := GetLengthFromAnnotation(DefaultLength(), instance.Annotations)
 	if err != nil {
 		return reconcile.Result{}, err
@@ -136,7 +192,13 @@ func (pg StringGenerator) regenerateKeysWhereRequired(instance *corev1.Secret, g
 		return reconcile.Result{}, err
 	}
 
+	hashes, err := GetDerivedHashesFromAnnotations(instance.Annotations)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
 	generatedCount := 0
+	keptPrevious := false
 	for _, key := range genKeys {
 		if len(instance.Data[key]) != 0 && !contains(regenKeys, key) {
 			// dont generate key if it already has a value
@@ -145,6 +207,10 @@ func (pg StringGenerator) regenerateKeysWhereRequired(instance *corev1.Secret, g
 		}
 		generatedCount++
 
+		if gracePeriod > 0 && KeepPreviousValue(instance.Data, key) {
+			keptPrevious = true
+		}
+
 		err = pg.generateRandomSecret(secretConfig{instance, key, parsedLength, isByteLength})
 		if err != nil {
 			pg.log.Error(err, "could not generate new random string")
@@ -154,10 +220,81 @@ func (pg StringGenerator) regenerateKeysWhereRequired(instance *corev1.Secret, g
 
 	pg.log.Info("generated secrets", "count", generatedCount)
 
+	// hashes are kept in sync with their keys, whether these were generated or not
+	for _, key := range genKeys {
+		if err = SetDerivedHashes(instance.Data, key, hashes); err != nil {
+			pg.log.Error(err, "could not hash value", "field", key)
+			return reconcile.Result{RequeueAfter: time.Second * 30}, err
+		}
+	}
+
 	if generatedCount == len(genKeys) {
 		// all keys have been generated by this instance
 		instance.Annotations[AnnotationSecretSecure] = "yes"
 	}
 
-	return reconcile.Result{}, nil
+	if keptPrevious {
+		SetPreviousValuesExpiry(instance, gracePeriod)
+	}
+
+	var requeueAfter time.Duration
+	if rotateAfter > 0 {
+		// newly generated keys are rotated rotateAfter from now, as the generation time is updated with the secret
+		requeueAfter = rotateAfter
+		if generatedCount == 0 && !nextRotation.IsZero() {
+			requeueAfter = time.Until(nextRotation)
+		}
+	}
+
+	return reconcile.Result{RequeueAfter: EarliestRequeue(requeueAfter, PreviousValuesExpireIn(instance))}, nil
+}
+
+// nextRotationTime returns the time at which the keys of instance are due for rotation. Keys with an unparsable
+// generation time are due for rotation immediately, as are keys generated by the operator without a generation time.
+// Other keys without a generation time may have been set by the user, so they are kept and the generation time is
+// set to now, scheduling their rotation after rotateAfter.
+func nextRotationTime(logger logr.Logger, instance *corev1.Secret, rotateAfter time.Duration) time.Time {
+	generatedAtValue, ok := instance.Annotations[AnnotationSecretAutoGeneratedAt]
+	if !ok {
+		if _, secure := instance.Annotations[AnnotationSecretSecure]; secure {
+			logger.Info("keys have no generation time, keys are due for rotation")
+			return time.Now()
+		}
+
+		logger.Info("keys have no generation time, scheduling rotation", "rotateAfter", rotateAfter)
+		now := time.Now()
+		instance.Annotations[AnnotationSecretAutoGeneratedAt] = now.Format(time.RFC3339)
+		return now.Add(rotateAfter)
+	}
+
+	generatedAt, err := time.Parse(time.RFC3339, generatedAtValue)
+	if err != nil {
+		logger.Info("could not parse generation time, keys are due for rotation", "generatedAt", generatedAtValue)
+		return time.Now()
+	}
+
+	return generatedAt.Add(rotateAfter)
+}
+
+// getRotateAfterFromAnnotation parses the rotation interval set in annotations. Zero is returned if no rotation
+// interval is set.
+func getRotateAfterFromAnnotation(annotations map[string]string) (time.Duration, error) {
+	return parseRotateAfter(annotations[AnnotationSecretRotateAfter])
+}
+
+// parseRotateAfter parses the given rotation interval. Zero is returned if value is empty.
+func parseRotateAfter(val string) (time.Duration, error) {
+	if val == "" {
+		return 0, nil
+	}
+
+	rotateAfter, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, err
+	}
+	if rotateAfter <= 0 {
+		return 0, fmt.Errorf("rotation interval %s must be positive", val)
+	}
+
+	return rotateAfter, nil
 }
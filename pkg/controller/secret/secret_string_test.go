@@ -14,6 +14,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
 )
@@ -418,6 +419,167 @@ func TestStringLengthFromAnnotation(t *testing.T) {
 	}
 }
 
+func TestRotateAfterRotatesKeys(t *testing.T) {
+	in := newStringTestSecret("testfield,test1", map[string]string{
+		secret.AnnotationSecretRotateAfter:     "1h",
+		secret.AnnotationSecretSecure:          "yes",
+		secret.AnnotationSecretAutoGeneratedAt: time.Now().Add(-2 * time.Hour).Format(time.RFC3339),
+	}, "test,test")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	rec := secret.NewReconciler(mgr)
+	res, err := rec.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: in.Name, Namespace: in.Namespace}})
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, res.RequeueAfter)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	verifyStringSecret(t, in, out, true)
+	for _, key := range []string{"testfield", "test1"} {
+		if bytes.Equal(in.Data[key], out.Data[key]) {
+			t.Errorf("key %s has not been rotated", key)
+		}
+	}
+}
+
+func TestRotateAfterRotatesKeysWithoutGenerationTime(t *testing.T) {
+	// values generated by the operator without a generation time are due immediately
+	in := newStringTestSecret("testfield", map[string]string{
+		secret.AnnotationSecretRotateAfter: "1h",
+		secret.AnnotationSecretSecure:      "yes",
+	}, "test")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	rec := secret.NewReconciler(mgr)
+	res, err := rec.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: in.Name, Namespace: in.Namespace}})
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, res.RequeueAfter)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	if bytes.Equal(in.Data["testfield"], out.Data["testfield"]) {
+		t.Error("key has not been rotated")
+	}
+	require.NotEmpty(t, out.Annotations[secret.AnnotationSecretAutoGeneratedAt])
+}
+
+func TestRotateAfterSchedulesRotationOfUserValues(t *testing.T) {
+	// values set by the creator of the Secret are kept until the rotation interval has passed
+	in := newStringTestSecret("testfield", map[string]string{
+		secret.AnnotationSecretRotateAfter: "1h",
+	}, "test")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	rec := secret.NewReconciler(mgr)
+	res, err := rec.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: in.Name, Namespace: in.Namespace}})
+	require.NoError(t, err)
+
+	if res.RequeueAfter > time.Hour || res.RequeueAfter < 59*time.Minute {
+		t.Errorf("secret is requeued after %s instead of at the next rotation", res.RequeueAfter)
+	}
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	require.Equal(t, "test", string(out.Data["testfield"]))
+	require.NotEmpty(t, out.Annotations[secret.AnnotationSecretAutoGeneratedAt])
+}
+
+func TestRotateAfterDoesNotRotateBeforeInterval(t *testing.T) {
+	in := newStringTestSecret("testfield", map[string]string{
+		secret.AnnotationSecretRotateAfter:     "1h",
+		secret.AnnotationSecretSecure:          "yes",
+		secret.AnnotationSecretAutoGeneratedAt: time.Now().Add(-30 * time.Minute).Format(time.RFC3339),
+	}, "test")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	rec := secret.NewReconciler(mgr)
+	res, err := rec.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: in.Name, Namespace: in.Namespace}})
+	require.NoError(t, err)
+
+	if res.RequeueAfter > 30*time.Minute || res.RequeueAfter < 29*time.Minute {
+		t.Errorf("secret is requeued after %s instead of at the next rotation", res.RequeueAfter)
+	}
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	if !bytes.Equal(in.Data["testfield"], out.Data["testfield"]) {
+		t.Error("key has been rotated before the rotation interval passed")
+	}
+}
+
+func TestRotateAfterInvalidAnnotation(t *testing.T) {
+	in := newStringTestSecret("testfield", map[string]string{
+		secret.AnnotationSecretRotateAfter: "90 days",
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, true)
+}
+
+func TestKeepPreviousValue(t *testing.T) {
+	in := newStringTestSecret("testfield", map[string]string{
+		secret.AnnotationSecretRegenerate:      "yes",
+		secret.AnnotationSecretKeepPrevious:    "1h",
+		secret.AnnotationSecretAutoGeneratedAt: time.Now().Format(time.RFC3339),
+	}, "test")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	rec := secret.NewReconciler(mgr)
+	res, err := rec.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: in.Name, Namespace: in.Namespace}})
+	require.NoError(t, err)
+
+	if res.RequeueAfter > time.Hour || res.RequeueAfter < 59*time.Minute {
+		t.Errorf("secret is requeued after %s instead of when the previous value expires", res.RequeueAfter)
+	}
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	verifyStringRegen(t, in, out)
+	require.Equal(t, "test", string(out.Data["testfield"+secret.SecretFieldPreviousSuffix]))
+	require.Contains(t, out.Annotations, secret.AnnotationSecretPreviousExpiry)
+}
+
+func TestPreviousValueIsPruned(t *testing.T) {
+	generatedAt := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	in := newStringTestSecret("testfield", map[string]string{
+		secret.AnnotationSecretType:            string(secret.TypeString),
+		secret.AnnotationSecretKeepPrevious:    "1h",
+		secret.AnnotationSecretSecure:          "yes",
+		secret.AnnotationSecretAutoGeneratedAt: generatedAt,
+		secret.AnnotationSecretPreviousExpiry:  time.Now().Add(-time.Hour).Format(time.RFC3339),
+	}, "test")
+	in.Data["testfield"+secret.SecretFieldPreviousSuffix] = []byte("previous")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	require.NotContains(t, out.Data, "testfield"+secret.SecretFieldPreviousSuffix)
+	require.NotContains(t, out.Annotations, secret.AnnotationSecretPreviousExpiry)
+	require.Equal(t, "test", string(out.Data["testfield"]))
+	// pruning must not delay rotations based on the generation time
+	require.Equal(t, generatedAt, out.Annotations[secret.AnnotationSecretAutoGeneratedAt])
+}
+
 func TestGeneratedSecretsHaveCorrectLength(t *testing.T) {
 	pwd, err := secret.GenerateRandomString(20, "base64", false)
 
@@ -0,0 +1,556 @@
+package secret
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	SecretFieldTLSCertificate = corev1.TLSCertKey
+	SecretFieldTLSPrivateKey  = corev1.TLSPrivateKeyKey
+	SecretFieldTLSCA          = "ca.crt"
+)
+
+const (
+	DefaultTLSKeyAlgorithm = KeyAlgorithmECDSAP256
+	DefaultTLSRSAKeyLength = 2048
+	DefaultTLSValidity     = 365 * 24 * time.Hour
+	DefaultCAValidity      = 10 * DefaultTLSValidity
+
+	// minRenewalRequeue is the minimum delay until a renewal is attempted again, preventing
+	// a renewal loop if the renewal window covers almost the whole validity of a certificate
+	minRenewalRequeue = time.Minute
+)
+
+type TLSGenerator struct {
+	log logr.Logger
+}
+
+type TLSConstraints struct {
+	CommonName string
+	// SANs contains DNS names, IP addresses, email addresses and URIs
+	SANs      []string
+	Algorithm string
+	Length    string
+	Format    string
+	Validity  string
+	// RenewBefore is the duration before expiry at which a certificate is reissued
+	RenewBefore string
+	// ReusePrivateKey keeps the existing private key when a certificate is reissued without
+	// an explicit regeneration
+	ReusePrivateKey bool
+}
+
+// Validate checks whether a certificate can be issued with the given constraints
+func (cons *TLSConstraints) Validate() error {
+	algorithm := cons.keyAlgorithm()
+	if err := algorithm.Validate(); err != nil {
+		return err
+	}
+
+	// the length is only taken into account for RSA keys
+	if algorithm == KeyAlgorithmRSA {
+		if _, err := ParseRSAKeyLength(DefaultTLSRSAKeyLength, cons.Length); err != nil {
+			return err
+		}
+	}
+
+	if err := validateTLSPrivateKeyFormat(cons.Format); err != nil {
+		return err
+	}
+
+	if _, err := parseValidity(cons, DefaultTLSValidity); err != nil {
+		return err
+	}
+
+	if _, err := parseRenewBefore(cons); err != nil {
+		return err
+	}
+
+	return addSubjectAltNames(&x509.Certificate{}, cons.SANs)
+}
+
+// keyAlgorithm returns the algorithm of cons, which defaults to DefaultTLSKeyAlgorithm
+func (cons *TLSConstraints) keyAlgorithm() KeyAlgorithm {
+	if cons.Algorithm == "" {
+		return DefaultTLSKeyAlgorithm
+	}
+	return KeyAlgorithm(cons.Algorithm)
+}
+
+func (tg TLSGenerator) generateData(instance *corev1.Secret) (reconcile.Result, error) {
+	regenerate := instance.Annotations[AnnotationSecretRegenerate] != ""
+
+	if regenerate {
+		delete(instance.Annotations, AnnotationSecretRegenerate)
+	}
+
+	commonName := instance.Annotations[AnnotationTLSCommonName]
+	if commonName == "" {
+		commonName = instance.Name
+	}
+
+	var sans []string
+	if val, ok := instance.Annotations[AnnotationTLSSubjectAltNames]; ok && val != "" {
+		sans = strings.Split(val, ",")
+	}
+
+	cons := &TLSConstraints{
+		CommonName: commonName,
+		SANs:       sans,
+		Algorithm:  instance.Annotations[AnnotationKeyAlgorithm],
+		Length:     instance.Annotations[AnnotationSecretLength],
+		Format:     instance.Annotations[AnnotationPrivateKeyFormat],
+		Validity:   instance.Annotations[AnnotationTLSValidity],
+
+		RenewBefore:     instance.Annotations[AnnotationTLSRenewBefore],
+		ReusePrivateKey: instance.Annotations[AnnotationTLSReusePrivateKey] == "true",
+	}
+
+	err := GenerateTLSData(tg.log, cons, regenerate, instance.Data)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	return RenewalResult(cons, instance.Data), nil
+}
+
+// GenerateTLSData generates a private key and a matching self-signed certificate and writes the PEM encoded
+// results to data. Existing values are only replaced if regenerate is true or the certificate is due for renewal.
+func GenerateTLSData(logger logr.Logger, cons *TLSConstraints, regenerate bool, data map[string][]byte) error {
+	if err := cons.Validate(); err != nil {
+		return err
+	}
+
+	if hasTLSData(data) && !regenerate {
+		if renew, err := dueForRenewal(logger, cons, data); err != nil || !renew {
+			return err
+		}
+	}
+
+	key, err := tlsPrivateKey(logger, cons, regenerate, data)
+	if err != nil {
+		return err
+	}
+
+	template, err := NewCertificateTemplate(cons, key.Public())
+	if err != nil {
+		logger.Error(err, "could not create certificate template")
+
+		return err
+	}
+
+	return issueCertificate(logger, cons, template, template, key, key, data)
+}
+
+// GenerateCAData generates a private key and a matching self-signed CA certificate and writes the PEM encoded
+// results to data. The certificate is stored in both the tls.crt and ca.crt keys. Existing values are only
+// replaced if regenerate is true or the certificate is due for renewal.
+func GenerateCAData(logger logr.Logger, cons *TLSConstraints, regenerate bool, data map[string][]byte) error {
+	if hasTLSData(data) && !regenerate {
+		if renew, err := dueForRenewal(logger, cons, data); err != nil || !renew {
+			return err
+		}
+	}
+
+	key, err := tlsPrivateKey(logger, cons, regenerate, data)
+	if err != nil {
+		return err
+	}
+
+	template, err := NewCATemplate(cons)
+	if err != nil {
+		logger.Error(err, "could not create CA certificate template")
+
+		return err
+	}
+
+	err = issueCertificate(logger, cons, template, template, key, key, data)
+	if err != nil {
+		return err
+	}
+
+	data[SecretFieldTLSCA] = data[SecretFieldTLSCertificate]
+
+	return nil
+}
+
+// GenerateSignedTLSData generates a private key and a matching certificate signed by the given PEM encoded
+// CA certificate and key. The results are written to data, along with the CA certificate. Existing values are
+// only replaced if regenerate is true, they have been issued by a different CA certificate or the certificate is
+// due for renewal.
+func GenerateSignedTLSData(logger logr.Logger, cons *TLSConstraints, caCertPEM, caKeyPEM []byte, regenerate bool, data map[string][]byte) error {
+	if hasTLSData(data) && bytes.Equal(data[SecretFieldTLSCA], caCertPEM) && !regenerate {
+		if renew, err := dueForRenewal(logger, cons, data); err != nil || !renew {
+			return err
+		}
+	}
+
+	caCert, err := ParseCertificatePEM(caCertPEM)
+	if err != nil {
+		logger.Error(err, "could not parse CA certificate")
+
+		return err
+	}
+
+	caKey, err := ParsePrivateKeyPEM(caKeyPEM)
+	if err != nil {
+		logger.Error(err, "could not parse CA private key")
+
+		return err
+	}
+
+	key, err := tlsPrivateKey(logger, cons, regenerate, data)
+	if err != nil {
+		return err
+	}
+
+	template, err := NewCertificateTemplate(cons, key.Public())
+	if err != nil {
+		logger.Error(err, "could not create certificate template")
+
+		return err
+	}
+
+	// certificates must not outlive their CA
+	if template.NotAfter.After(caCert.NotAfter) {
+		template.NotAfter = caCert.NotAfter
+	}
+
+	err = issueCertificate(logger, cons, template, caCert, key, caKey, data)
+	if err != nil {
+		return err
+	}
+
+	data[SecretFieldTLSCA] = caCertPEM
+
+	return nil
+}
+
+// hasTLSData checks whether data already contains a certificate and a private key
+func hasTLSData(data map[string][]byte) bool {
+	return len(data[SecretFieldTLSCertificate]) > 0 && len(data[SecretFieldTLSPrivateKey]) > 0
+}
+
+// RenewalResult returns a reconcile.Result requeueing the request once the certificate in data is due for renewal.
+// If data contains no valid certificate, no requeue is requested.
+func RenewalResult(cons *TLSConstraints, data map[string][]byte) reconcile.Result {
+	renewBefore, err := parseRenewBefore(cons)
+	if err != nil {
+		return reconcile.Result{}
+	}
+
+	cert, err := ParseCertificatePEM(data[SecretFieldTLSCertificate])
+	if err != nil {
+		return reconcile.Result{}
+	}
+
+	requeueAfter := time.Until(CertificateRenewalTime(cert, renewBefore))
+	if requeueAfter < minRenewalRequeue {
+		requeueAfter = minRenewalRequeue
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}
+}
+
+// CertificateRenewalTime returns the point in time at which cert should be renewed. If renewBefore is not positive
+// or exceeds the lifetime of cert, the certificate is renewed after two thirds of its lifetime.
+func CertificateRenewalTime(cert *x509.Certificate, renewBefore time.Duration) time.Time {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	if renewBefore <= 0 || renewBefore >= lifetime {
+		renewBefore = lifetime / 3
+	}
+
+	return cert.NotAfter.Add(-renewBefore)
+}
+
+// dueForRenewal checks whether the certificate in data has reached its renewal time. Certificates that cannot be
+// parsed are always due for renewal.
+func dueForRenewal(logger logr.Logger, cons *TLSConstraints, data map[string][]byte) (bool, error) {
+	renewBefore, err := parseRenewBefore(cons)
+	if err != nil {
+		logger.Error(err, "could not parse certificate renewal window")
+
+		return false, err
+	}
+
+	cert, err := ParseCertificatePEM(data[SecretFieldTLSCertificate])
+	if err != nil {
+		logger.Info("existing certificate could not be parsed, reissuing", "error", err.Error())
+
+		return true, nil
+	}
+
+	renewalTime := CertificateRenewalTime(cert, renewBefore)
+	if time.Now().Before(renewalTime) {
+		return false, nil
+	}
+
+	logger.Info("certificate is due for renewal", "notAfter", cert.NotAfter, "renewalTime", renewalTime)
+
+	return true, nil
+}
+
+// parseRenewBefore returns the renewal window configured in cons, falling back to the operator's default
+func parseRenewBefore(cons *TLSConstraints) (time.Duration, error) {
+	if cons.RenewBefore == "" {
+		return CertificateRenewBefore(), nil
+	}
+
+	renewBefore, err := time.ParseDuration(cons.RenewBefore)
+	if err != nil {
+		return 0, err
+	}
+	if renewBefore < 0 {
+		return 0, fmt.Errorf("certificate renewal window %s must not be negative", cons.RenewBefore)
+	}
+
+	return renewBefore, nil
+}
+
+// tlsPrivateKey returns the private key for a new certificate. If cons requests reusing the private key and
+// no regeneration has been requested, the existing key in data is returned, otherwise a new key is generated.
+func tlsPrivateKey(logger logr.Logger, cons *TLSConstraints, regenerate bool, data map[string][]byte) (crypto.Signer, error) {
+	if cons.ReusePrivateKey && !regenerate && len(data[SecretFieldTLSPrivateKey]) > 0 {
+		key, err := ParsePrivateKeyPEM(data[SecretFieldTLSPrivateKey])
+		if err == nil {
+			return key, nil
+		}
+
+		logger.Info("existing private key could not be parsed, generating new key", "error", err.Error())
+	}
+
+	return generateTLSPrivateKey(logger, cons)
+}
+
+// issueCertificate creates a certificate for key from template, signs it using parent and parentKey and writes the
+// PEM encoded certificate and key to data
+func issueCertificate(logger logr.Logger, cons *TLSConstraints, template, parent *x509.Certificate,
+	key, parentKey crypto.Signer, data map[string][]byte) error {
+	cert, err := CreateCertificatePEM(template, parent, key.Public(), parentKey)
+	if err != nil {
+		logger.Error(err, "could not create certificate")
+
+		return err
+	}
+
+	keyPEM, err := encodeTLSPrivateKey(key, cons.Format)
+	if err != nil {
+		return err
+	}
+
+	data[SecretFieldTLSCertificate] = cert
+	data[SecretFieldTLSPrivateKey] = keyPEM
+
+	logger.Info("generated new certificate", "commonName", cons.CommonName, "notAfter", template.NotAfter)
+
+	return nil
+}
+
+// generateTLSPrivateKey generates a new private key for a certificate matching the given constraints
+func generateTLSPrivateKey(logger logr.Logger, cons *TLSConstraints) (crypto.Signer, error) {
+	algorithm := cons.keyAlgorithm()
+	if err := algorithm.Validate(); err != nil {
+		logger.Error(err, "could not determine algorithm for new certificate")
+
+		return nil, err
+	}
+
+	var length int
+	if algorithm == KeyAlgorithmRSA {
+		var err error
+		if length, err = ParseRSAKeyLength(DefaultTLSRSAKeyLength, cons.Length); err != nil {
+			logger.Error(err, "could not parse length for new certificate key")
+
+			return nil, err
+		}
+	}
+
+	return GeneratePrivateKey(algorithm, length)
+}
+
+// validateTLSPrivateKeyFormat checks whether private keys of certificates can be encoded in the given format.
+// TLS implementations cannot read keys in the OpenSSH format.
+func validateTLSPrivateKeyFormat(format string) error {
+	switch pf := PrivateKeyFormat(format); pf {
+	case "", PrivateKeyFormatPKCS1, PrivateKeyFormatPKCS8:
+		return nil
+	}
+	return fmt.Errorf("%s is not a valid private key format for certificates, must be one of %s, %s", format,
+		PrivateKeyFormatPKCS1, PrivateKeyFormatPKCS8)
+}
+
+// encodeTLSPrivateKey PEM encodes the given key, using PKCS#8 unless another format is requested
+func encodeTLSPrivateKey(key crypto.Signer, format string) ([]byte, error) {
+	if err := validateTLSPrivateKeyFormat(format); err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = string(PrivateKeyFormatPKCS8)
+	}
+
+	block, err := MarshalPrivateKeyPEM(key, PrivateKeyFormat(format), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(block), nil
+}
+
+// NewCertificateTemplate creates a certificate template for a leaf certificate for pub, which is valid for server and
+// client authentication, from the given constraints
+func NewCertificateTemplate(cons *TLSConstraints, pub crypto.PublicKey) (*x509.Certificate, error) {
+	template, err := newBaseTemplate(cons, DefaultTLSValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+
+	if _, ok := pub.(*rsa.PublicKey); ok {
+		// RSA keys may be used for key exchange as well
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	if err = addSubjectAltNames(template, cons.SANs); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// NewCATemplate creates a certificate template for a CA certificate, which may only issue leaf certificates,
+// from the given constraints
+func NewCATemplate(cons *TLSConstraints) (*x509.Certificate, error) {
+	template, err := newBaseTemplate(cons, DefaultCAValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	template.IsCA = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	return template, nil
+}
+
+// newBaseTemplate creates a certificate template with a random serial number, the common name and
+// validity from cons, falling back to defaultValidity if cons contains no validity
+func newBaseTemplate(cons *TLSConstraints, defaultValidity time.Duration) (*x509.Certificate, error) {
+	validity, err := parseValidity(cons, defaultValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: cons.CommonName},
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.Add(validity),
+		BasicConstraintsValid: true,
+	}, nil
+}
+
+// parseValidity returns the validity configured in cons, falling back to defaultValidity
+func parseValidity(cons *TLSConstraints, defaultValidity time.Duration) (time.Duration, error) {
+	if cons.Validity == "" {
+		return defaultValidity, nil
+	}
+
+	validity, err := time.ParseDuration(cons.Validity)
+	if err != nil {
+		return 0, err
+	}
+	if validity <= 0 {
+		return 0, fmt.Errorf("certificate validity %s must be positive", cons.Validity)
+	}
+
+	return validity, nil
+}
+
+// addSubjectAltNames sorts the given subject alternative names into IP addresses, email addresses,
+// URIs and DNS names and adds them to template
+func addSubjectAltNames(template *x509.Certificate, sans []string) error {
+	for _, san := range sans {
+		san = strings.TrimSpace(san)
+		if san == "" {
+			continue
+		}
+
+		if ip := net.ParseIP(san); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+			continue
+		}
+
+		if strings.Contains(san, "://") {
+			uri, err := url.Parse(san)
+			if err != nil {
+				return err
+			}
+			template.URIs = append(template.URIs, uri)
+			continue
+		}
+
+		if strings.Contains(san, "@") {
+			if _, err := mail.ParseAddress(san); err != nil {
+				return err
+			}
+			template.EmailAddresses = append(template.EmailAddresses, san)
+			continue
+		}
+
+		template.DNSNames = append(template.DNSNames, san)
+	}
+
+	return nil
+}
+
+// CreateCertificatePEM creates a certificate from template, signs it with the parent's signer and returns it PEM encoded
+func CreateCertificatePEM(template, parent *x509.Certificate, pub crypto.PublicKey, signer crypto.Signer) ([]byte, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	certBytes := &bytes.Buffer{}
+	err = pem.Encode(certBytes, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err != nil {
+		return nil, err
+	}
+
+	return certBytes.Bytes(), nil
+}
+
+// ParseCertificatePEM parses the first PEM encoded certificate in pemCert
+func ParseCertificatePEM(pemCert []byte) (*x509.Certificate, error) {
+	b, _ := pem.Decode(pemCert)
+	if b == nil || b.Type != "CERTIFICATE" {
+		return nil, errors.New("failed to parse certificate PEM block")
+	}
+
+	return x509.ParseCertificate(b.Bytes)
+}
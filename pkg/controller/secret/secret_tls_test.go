@@ -0,0 +1,292 @@
+package secret_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/imdario/mergo"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+func newTLSTestSecret(extraAnnotations map[string]string) *corev1.Secret {
+	annotations := map[string]string{
+		secret.AnnotationSecretType: string(secret.TypeTLS),
+	}
+
+	if extraAnnotations != nil {
+		if err := mergo.Merge(&annotations, extraAnnotations, mergo.WithOverride); err != nil {
+			panic(err)
+		}
+	}
+
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSecretName(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+			Annotations: annotations,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       {},
+			corev1.TLSPrivateKeyKey: {},
+		},
+	}
+
+	return s
+}
+
+func reconcileTLSTestSecret(t *testing.T, in *corev1.Secret) *corev1.Secret {
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	return out
+}
+
+func verifyTLSSecret(t *testing.T, out *corev1.Secret) {
+	if _, ok := out.Annotations[secret.AnnotationSecretAutoGeneratedAt]; !ok {
+		t.Errorf("secret has no %s annotation", secret.AnnotationSecretAutoGeneratedAt)
+	}
+
+	_, err := tls.X509KeyPair(out.Data[secret.SecretFieldTLSCertificate], out.Data[secret.SecretFieldTLSPrivateKey])
+	if err != nil {
+		t.Error(err, "generated certificate and key do not match")
+	}
+}
+
+func TestTLSIsGenerated(t *testing.T) {
+	in := newTLSTestSecret(map[string]string{
+		secret.AnnotationTLSSubjectAltNames: "example.default.svc,10.0.0.1",
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	out := reconcileTLSTestSecret(t, in)
+	verifyTLSSecret(t, out)
+
+	cert, err := secret.ParseCertificatePEM(out.Data[secret.SecretFieldTLSCertificate])
+	require.NoError(t, err)
+
+	require.Equal(t, in.Name, cert.Subject.CommonName)
+	require.Equal(t, []string{"example.default.svc"}, cert.DNSNames)
+	require.Len(t, cert.IPAddresses, 1)
+	require.Equal(t, "10.0.0.1", cert.IPAddresses[0].String())
+	require.NoError(t, cert.CheckSignatureFrom(cert))
+}
+
+func TestTLSCommonNameAnnotation(t *testing.T) {
+	in := newTLSTestSecret(map[string]string{
+		secret.AnnotationTLSCommonName: "example.default.svc",
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	out := reconcileTLSTestSecret(t, in)
+	verifyTLSSecret(t, out)
+
+	cert, err := secret.ParseCertificatePEM(out.Data[secret.SecretFieldTLSCertificate])
+	require.NoError(t, err)
+
+	require.Equal(t, "example.default.svc", cert.Subject.CommonName)
+}
+
+func TestTLSValidityAnnotation(t *testing.T) {
+	in := newTLSTestSecret(map[string]string{
+		secret.AnnotationTLSValidity: "48h",
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	out := reconcileTLSTestSecret(t, in)
+	verifyTLSSecret(t, out)
+
+	cert, err := secret.ParseCertificatePEM(out.Data[secret.SecretFieldTLSCertificate])
+	require.NoError(t, err)
+
+	remaining := time.Until(cert.NotAfter)
+	if remaining > 48*time.Hour || remaining < 47*time.Hour {
+		t.Errorf("certificate has wrong validity, expires at %s", cert.NotAfter)
+	}
+}
+
+func TestTLSInvalidValidityAnnotation(t *testing.T) {
+	in := newTLSTestSecret(map[string]string{
+		secret.AnnotationTLSValidity: "one year",
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, true)
+}
+
+func TestTLSOpenSSHPrivateKeyFormatAnnotation(t *testing.T) {
+	in := newTLSTestSecret(map[string]string{
+		secret.AnnotationPrivateKeyFormat: string(secret.PrivateKeyFormatOpenSSH),
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, true)
+}
+
+func TestTLSConstraintsValidate(t *testing.T) {
+	valid := []*secret.TLSConstraints{
+		{},
+		{Format: string(secret.PrivateKeyFormatPKCS8)},
+		{Algorithm: string(secret.KeyAlgorithmRSA), Format: string(secret.PrivateKeyFormatPKCS1)},
+		{Algorithm: string(secret.KeyAlgorithmRSA), Length: "3072"},
+	}
+	for _, cons := range valid {
+		require.NoError(t, cons.Validate(), cons.Format)
+	}
+
+	// certificates and certificate authorities are validated with the same constraints
+	invalid := []*secret.TLSConstraints{
+		{Format: string(secret.PrivateKeyFormatOpenSSH)},
+		{Format: "ppk"},
+		{Algorithm: "dsa"},
+		{Validity: "one year"},
+		{Algorithm: string(secret.KeyAlgorithmRSA), Length: "1024"},
+		{Algorithm: string(secret.KeyAlgorithmRSA), Length: "256b"},
+	}
+	for _, cons := range invalid {
+		require.Error(t, cons.Validate(), cons.Format)
+	}
+}
+
+func TestTLSKeyAlgorithmAnnotation(t *testing.T) {
+	in := newTLSTestSecret(map[string]string{
+		secret.AnnotationKeyAlgorithm: string(secret.KeyAlgorithmRSA),
+		secret.AnnotationSecretLength: "3072",
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	out := reconcileTLSTestSecret(t, in)
+	verifyTLSSecret(t, out)
+
+	key, err := secret.ParsePrivateKeyPEM(out.Data[secret.SecretFieldTLSPrivateKey])
+	require.NoError(t, err)
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		t.Fatalf("generated private key has wrong type %T", key)
+	}
+
+	// Size() returns size in bytes
+	if rsaKey.Size()*8 != 3072 {
+		t.Error("wrong generated key length")
+	}
+}
+
+func TestTLSIsNotRegenerated(t *testing.T) {
+	in := newTLSTestSecret(nil)
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	out := reconcileTLSTestSecret(t, in)
+	verifyTLSSecret(t, out)
+
+	outNew := reconcileTLSTestSecret(t, out)
+
+	if !bytes.Equal(out.Data[secret.SecretFieldTLSCertificate], outNew.Data[secret.SecretFieldTLSCertificate]) {
+		t.Error("certificate has been regenerated")
+	}
+}
+
+func TestTLSIsRegenerated(t *testing.T) {
+	in := newTLSTestSecret(nil)
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	out := reconcileTLSTestSecret(t, in)
+	verifyTLSSecret(t, out)
+
+	out.Annotations[secret.AnnotationSecretRegenerate] = "yes"
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), out))
+
+	outNew := reconcileTLSTestSecret(t, out)
+	verifyTLSSecret(t, outNew)
+
+	if _, ok := outNew.Annotations[secret.AnnotationSecretRegenerate]; ok {
+		t.Errorf("%s annotation is still present", secret.AnnotationSecretRegenerate)
+	}
+
+	if bytes.Equal(out.Data[secret.SecretFieldTLSCertificate], outNew.Data[secret.SecretFieldTLSCertificate]) {
+		t.Error("certificate has not been regenerated")
+	}
+
+	if bytes.Equal(out.Data[secret.SecretFieldTLSPrivateKey], outNew.Data[secret.SecretFieldTLSPrivateKey]) {
+		t.Error("private key has not been regenerated")
+	}
+}
+
+func TestTLSRequeuesForRenewal(t *testing.T) {
+	in := newTLSTestSecret(map[string]string{
+		secret.AnnotationTLSValidity:    "48h",
+		secret.AnnotationTLSRenewBefore: "24h",
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	rec := secret.NewReconciler(mgr)
+	res, err := rec.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: in.Name, Namespace: in.Namespace}})
+	require.NoError(t, err)
+
+	if res.RequeueAfter > 24*time.Hour || res.RequeueAfter < 23*time.Hour {
+		t.Errorf("secret is requeued after %s instead of shortly before expiry", res.RequeueAfter)
+	}
+}
+
+func TestTLSIsRenewed(t *testing.T) {
+	// the renewal window covers the whole validity, so the certificate is due for renewal right away
+	in := newTLSTestSecret(map[string]string{
+		secret.AnnotationTLSValidity:    "10m",
+		secret.AnnotationTLSRenewBefore: "14m",
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	out := reconcileTLSTestSecret(t, in)
+	verifyTLSSecret(t, out)
+
+	outNew := reconcileTLSTestSecret(t, out)
+	verifyTLSSecret(t, outNew)
+
+	if bytes.Equal(out.Data[secret.SecretFieldTLSCertificate], outNew.Data[secret.SecretFieldTLSCertificate]) {
+		t.Error("certificate has not been renewed")
+	}
+
+	if bytes.Equal(out.Data[secret.SecretFieldTLSPrivateKey], outNew.Data[secret.SecretFieldTLSPrivateKey]) {
+		t.Error("private key has not been regenerated")
+	}
+}
+
+func TestTLSIsRenewedWithExistingKey(t *testing.T) {
+	in := newTLSTestSecret(map[string]string{
+		secret.AnnotationTLSValidity:        "10m",
+		secret.AnnotationTLSRenewBefore:     "14m",
+		secret.AnnotationTLSReusePrivateKey: "true",
+	})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	out := reconcileTLSTestSecret(t, in)
+	verifyTLSSecret(t, out)
+
+	outNew := reconcileTLSTestSecret(t, out)
+	verifyTLSSecret(t, outNew)
+
+	if bytes.Equal(out.Data[secret.SecretFieldTLSCertificate], outNew.Data[secret.SecretFieldTLSCertificate]) {
+		t.Error("certificate has not been renewed")
+	}
+
+	if !bytes.Equal(out.Data[secret.SecretFieldTLSPrivateKey], outNew.Data[secret.SecretFieldTLSPrivateKey]) {
+		t.Error("private key has been regenerated")
+	}
+}
@@ -0,0 +1,231 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/skip2/go-qrcode"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	FieldTOTPSeed   = "seed"
+	FieldTOTPURI    = "uri"
+	FieldTOTPQRCode = "qrcode.png"
+)
+
+const (
+	// DefaultTOTPSeedLength is the length of seeds in base32 characters, which corresponds to the 160 bits
+	// recommended by RFC 4226
+	DefaultTOTPSeedLength = 32
+	DefaultTOTPDigits     = 6
+	DefaultTOTPPeriod     = 30
+	// totpQRCodeSize is the width and height of generated QR codes in pixels
+	totpQRCodeSize = 256
+)
+
+type TOTPGenerator struct {
+	log logr.Logger
+}
+
+// TOTPConstraints configures the generation of TOTP seeds and the otpauth URI describing them
+type TOTPConstraints struct {
+	Issuer  string
+	Account string
+	// Digits is the number of digits of the generated codes, 6 or 8
+	Digits int
+	// Period is the number of seconds a code is valid for
+	Period int
+	// QRCode enables the generation of a PNG QR code of the otpauth URI
+	QRCode bool
+	Length string
+}
+
+func (tg TOTPGenerator) generateData(instance *corev1.Secret) (reconcile.Result, error) {
+	regenerate := instance.Annotations[AnnotationSecretRegenerate] != ""
+
+	if regenerate {
+		delete(instance.Annotations, AnnotationSecretRegenerate)
+	}
+
+	cons, err := GetTOTPConstraintsFromAnnotations(instance.Name, instance.Annotations)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err = GenerateTOTPData(tg.log, cons, regenerate, instance.Data); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// GetTOTPConstraintsFromAnnotations returns the constraints of a TOTP seed configured by annotations. The account
+// defaults to name.
+func GetTOTPConstraintsFromAnnotations(name string, annotations map[string]string) (*TOTPConstraints, error) {
+	cons := &TOTPConstraints{
+		Issuer:  annotations[AnnotationTOTPIssuer],
+		Account: annotations[AnnotationTOTPAccount],
+		Digits:  DefaultTOTPDigits,
+		Period:  DefaultTOTPPeriod,
+		Length:  annotations[AnnotationSecretLength],
+	}
+	if cons.Account == "" {
+		cons.Account = name
+	}
+
+	var err error
+	if value, ok := annotations[AnnotationTOTPDigits]; ok {
+		if cons.Digits, err = parseTOTPDigits(value); err != nil {
+			return nil, err
+		}
+	}
+	if value, ok := annotations[AnnotationTOTPPeriod]; ok {
+		if cons.Period, err = parseTOTPPeriod(value); err != nil {
+			return nil, err
+		}
+	}
+	if value, ok := annotations[AnnotationTOTPQRCode]; ok {
+		if cons.QRCode, err = strconv.ParseBool(value); err != nil {
+			return nil, err
+		}
+	}
+
+	return cons, cons.Validate()
+}
+
+// Validate checks whether a TOTP seed and its otpauth URI can be generated with the given constraints
+func (cons *TOTPConstraints) Validate() error {
+	if cons.Account == "" {
+		return errors.New("account must be set")
+	}
+	if err := validateTOTPLabel(cons.Issuer); err != nil {
+		return err
+	}
+	if err := validateTOTPLabel(cons.Account); err != nil {
+		return err
+	}
+	if cons.Digits != 6 && cons.Digits != 8 {
+		return fmt.Errorf("digits must be 6 or 8, got %d", cons.Digits)
+	}
+	if cons.Period <= 0 {
+		return fmt.Errorf("period must be positive, got %d", cons.Period)
+	}
+
+	_, _, err := ParseByteLength(DefaultTOTPSeedLength, cons.Length)
+
+	return err
+}
+
+// validateTOTPLabel checks whether value can be used as issuer or account in the label of an otpauth URI
+func validateTOTPLabel(value string) error {
+	// the issuer and account are separated by a colon in the label
+	if strings.Contains(value, ":") {
+		return fmt.Errorf("issuer or account %s must not contain colons", value)
+	}
+	return nil
+}
+
+// parseTOTPDigits parses the number of digits of generated codes
+func parseTOTPDigits(value string) (int, error) {
+	digits, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if digits != 6 && digits != 8 {
+		return 0, fmt.Errorf("digits must be 6 or 8, got %d", digits)
+	}
+	return digits, nil
+}
+
+// parseTOTPPeriod parses the number of seconds a code is valid for
+func parseTOTPPeriod(value string) (int, error) {
+	period, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if period <= 0 {
+		return 0, fmt.Errorf("period must be positive, got %d", period)
+	}
+	return period, nil
+}
+
+// TOTPFields returns the keys of the Secret generated with the given constraints
+func TOTPFields(cons *TOTPConstraints) []string {
+	fields := []string{FieldTOTPSeed, FieldTOTPURI}
+	if cons.QRCode {
+		fields = append(fields, FieldTOTPQRCode)
+	}
+
+	return fields
+}
+
+// GenerateTOTPData generates a base32 encoded seed unless data already holds one or regenerate is set. The otpauth
+// URI and the QR code are derived from the seed and cons on every call, so they follow changes of the constraints.
+func GenerateTOTPData(logger logr.Logger, cons *TOTPConstraints, regenerate bool, data map[string][]byte) error {
+	if err := cons.Validate(); err != nil {
+		return err
+	}
+
+	if len(data[FieldTOTPSeed]) == 0 || regenerate {
+		length, isByteLength, err := ParseByteLength(DefaultTOTPSeedLength, cons.Length)
+		if err != nil {
+			return err
+		}
+
+		seed, err := GenerateRandomString(length, "base32", isByteLength)
+		if err != nil {
+			logger.Error(err, "could not generate seed")
+			return err
+		}
+		// authenticator apps do not accept padded seeds
+		data[FieldTOTPSeed] = []byte(strings.TrimRight(string(seed), "="))
+	}
+
+	uri := TOTPURI(cons, string(data[FieldTOTPSeed]))
+	data[FieldTOTPURI] = []byte(uri)
+
+	if !cons.QRCode {
+		delete(data, FieldTOTPQRCode)
+		return nil
+	}
+
+	png, err := qrcode.Encode(uri, qrcode.Medium, totpQRCodeSize)
+	if err != nil {
+		logger.Error(err, "could not encode QR code")
+		return err
+	}
+	data[FieldTOTPQRCode] = png
+
+	return nil
+}
+
+// TOTPURI returns the otpauth URI of seed in the key URI format of Google Authenticator
+func TOTPURI(cons *TOTPConstraints, seed string) string {
+	label := cons.Account
+	query := url.Values{}
+	query.Set("secret", seed)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(cons.Digits))
+	query.Set("period", strconv.Itoa(cons.Period))
+	if cons.Issuer != "" {
+		label = cons.Issuer + ":" + label
+		query.Set("issuer", cons.Issuer)
+	}
+
+	uri := url.URL{
+		Scheme: "otpauth",
+		Host:   "totp",
+		Path:   "/" + label,
+		// spaces are encoded as %20, as some authenticator apps do not decode + in the issuer
+		RawQuery: strings.ReplaceAll(query.Encode(), "+", "%20"),
+	}
+
+	return uri.String()
+}
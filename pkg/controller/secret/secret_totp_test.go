@@ -0,0 +1,97 @@
+package secret_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base32"
+	"image/png"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+func TestGenerateTOTPData(t *testing.T) {
+	cons := &secret.TOTPConstraints{Issuer: "ACME Corp", Account: "admin", Digits: 8, Period: 60, QRCode: true}
+	data := make(map[string][]byte)
+
+	require.NoError(t, secret.GenerateTOTPData(logf.Log, cons, false, data))
+
+	seed := string(data[secret.FieldTOTPSeed])
+	require.Len(t, seed, secret.DefaultTOTPSeedLength)
+	decoded, err := base32.StdEncoding.DecodeString(seed)
+	require.NoError(t, err)
+	require.Len(t, decoded, 20)
+
+	uri, err := url.Parse(string(data[secret.FieldTOTPURI]))
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, "/ACME Corp:admin", uri.Path)
+	require.Equal(t, seed, uri.Query().Get("secret"))
+	require.Equal(t, "ACME Corp", uri.Query().Get("issuer"))
+	require.Equal(t, "8", uri.Query().Get("digits"))
+	require.Equal(t, "60", uri.Query().Get("period"))
+
+	_, err = png.Decode(bytes.NewReader(data[secret.FieldTOTPQRCode]))
+	require.NoError(t, err)
+
+	// the seed is kept, while the URI follows changes of the constraints
+	cons.QRCode = false
+	cons.Issuer = ""
+	require.NoError(t, secret.GenerateTOTPData(logf.Log, cons, false, data))
+	require.Equal(t, seed, string(data[secret.FieldTOTPSeed]))
+	require.Equal(t, "otpauth://totp/admin?algorithm=SHA1&digits=8&period=60&secret="+seed, string(data[secret.FieldTOTPURI]))
+	require.NotContains(t, data, secret.FieldTOTPQRCode)
+
+	require.NoError(t, secret.GenerateTOTPData(logf.Log, cons, true, data))
+	require.NotEqual(t, seed, string(data[secret.FieldTOTPSeed]))
+}
+
+func TestGetTOTPConstraintsFromAnnotations(t *testing.T) {
+	cons, err := secret.GetTOTPConstraintsFromAnnotations("name", map[string]string{})
+	require.NoError(t, err)
+	require.Equal(t, &secret.TOTPConstraints{Account: "name", Digits: 6, Period: 30}, cons)
+
+	_, err = secret.GetTOTPConstraintsFromAnnotations("name", map[string]string{secret.AnnotationTOTPDigits: "7"})
+	require.Error(t, err)
+	_, err = secret.GetTOTPConstraintsFromAnnotations("name", map[string]string{secret.AnnotationTOTPPeriod: "0"})
+	require.Error(t, err)
+	_, err = secret.GetTOTPConstraintsFromAnnotations("name", map[string]string{secret.AnnotationTOTPIssuer: "a:b"})
+	require.Error(t, err)
+}
+
+func TestTOTPIsGenerated(t *testing.T) {
+	in := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSecretName(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+			Annotations: map[string]string{
+				secret.AnnotationSecretType:  string(secret.TypeTOTP),
+				secret.AnnotationTOTPIssuer:  "ACME",
+				secret.AnnotationTOTPQRCode:  "true",
+				secret.AnnotationTOTPAccount: "break-glass",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{},
+	}
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.NotEmpty(t, out.Data[secret.FieldTOTPSeed])
+	require.Contains(t, string(out.Data[secret.FieldTOTPURI]), "otpauth://totp/ACME:break-glass?")
+	require.NotEmpty(t, out.Data[secret.FieldTOTPQRCode])
+}
@@ -0,0 +1,160 @@
+package secret
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	FieldWireGuardPrivateKey   = "privatekey"
+	FieldWireGuardPublicKey    = "publickey"
+	FieldWireGuardPresharedKey = "presharedkey"
+)
+
+// wireGuardKeyLen is the length of WireGuard's Curve25519 keys and preshared keys in bytes
+const wireGuardKeyLen = 32
+
+type WireGuardKeypairGenerator struct {
+	log logr.Logger
+}
+
+// WireGuardKeypairConstraints configures the generation of WireGuard key pairs
+type WireGuardKeypairConstraints struct {
+	// PresharedKey enables the generation of a preshared key, which adds a symmetric layer of encryption
+	PresharedKey bool
+}
+
+func (wg WireGuardKeypairGenerator) generateData(instance *corev1.Secret) (reconcile.Result, error) {
+	regenerate := instance.Annotations[AnnotationSecretRegenerate] != ""
+
+	if regenerate {
+		delete(instance.Annotations, AnnotationSecretRegenerate)
+	}
+
+	cons, err := GetWireGuardConstraintsFromAnnotations(instance.Annotations)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err = GenerateWireGuardKeypairData(wg.log, cons, regenerate, instance.Data); err != nil {
+		return reconcile.Result{RequeueAfter: time.Second * 30}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// GetWireGuardConstraintsFromAnnotations returns the constraints of a WireGuard key pair configured by annotations
+func GetWireGuardConstraintsFromAnnotations(annotations map[string]string) (*WireGuardKeypairConstraints, error) {
+	cons := &WireGuardKeypairConstraints{}
+
+	if value, ok := annotations[AnnotationWireGuardPresharedKey]; ok {
+		presharedKey, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+		cons.PresharedKey = presharedKey
+	}
+
+	return cons, nil
+}
+
+// WireGuardKeypairFields returns the keys of the Secret generated with the given constraints
+func WireGuardKeypairFields(cons *WireGuardKeypairConstraints) []string {
+	fields := []string{FieldWireGuardPrivateKey, FieldWireGuardPublicKey}
+	if cons.PresharedKey {
+		fields = append(fields, FieldWireGuardPresharedKey)
+	}
+
+	return fields
+}
+
+// GenerateWireGuardKeypairData generates a Curve25519 private key and its public key in the base64 encoding of wg(8)
+// and writes them to data, along with a preshared key if requested. Existing keys are kept unless regenerate is set,
+// a missing public key is derived from the existing private key.
+func GenerateWireGuardKeypairData(logger logr.Logger, cons *WireGuardKeypairConstraints, regenerate bool, data map[string][]byte) error {
+	if cons.PresharedKey && (regenerate || len(data[FieldWireGuardPresharedKey]) == 0) {
+		presharedKey, err := GenerateRandomString(wireGuardKeyLen, "raw", true)
+		if err != nil {
+			logger.Error(err, "could not generate preshared key")
+			return err
+		}
+		data[FieldWireGuardPresharedKey] = []byte(base64.StdEncoding.EncodeToString(presharedKey))
+	}
+
+	privateKey := data[FieldWireGuardPrivateKey]
+	if len(privateKey) > 0 && !regenerate {
+		return CheckAndRegenWireGuardPublicKey(data, data[FieldWireGuardPublicKey], privateKey)
+	}
+
+	key, err := generateWireGuardKey()
+	if err != nil {
+		logger.Error(err, "could not generate private key")
+		return err
+	}
+
+	publicKey, err := wireGuardPublicKey(key)
+	if err != nil {
+		return err
+	}
+
+	data[FieldWireGuardPrivateKey] = []byte(base64.StdEncoding.EncodeToString(key))
+	data[FieldWireGuardPublicKey] = publicKey
+
+	return nil
+}
+
+// CheckAndRegenWireGuardPublicKey checks if the specified public key has length > 0 and derives it from the given
+// base64 encoded private key otherwise. The result is written into data
+func CheckAndRegenWireGuardPublicKey(data map[string][]byte, publicKey, privateKey []byte) error {
+	if len(publicKey) > 0 {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(string(privateKey))
+	if err != nil {
+		return fmt.Errorf("could not decode private key: %w", err)
+	}
+
+	publicKey, err = wireGuardPublicKey(key)
+	if err != nil {
+		return err
+	}
+	data[FieldWireGuardPublicKey] = publicKey
+
+	return nil
+}
+
+// generateWireGuardKey returns a random private key clamped like the keys of wg genkey
+func generateWireGuardKey() ([]byte, error) {
+	key := make([]byte, wireGuardKeyLen)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	key[0] &= 248
+	key[31] = (key[31] & 127) | 64
+
+	return key, nil
+}
+
+// wireGuardPublicKey returns the base64 encoded public key of the given raw private key
+func wireGuardPublicKey(privateKey []byte) ([]byte, error) {
+	if len(privateKey) != wireGuardKeyLen {
+		return nil, fmt.Errorf("private key must be %d bytes long, got %d", wireGuardKeyLen, len(privateKey))
+	}
+
+	key, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(base64.StdEncoding.EncodeToString(key.PublicKey().Bytes())), nil
+}
@@ -0,0 +1,163 @@
+package secret_test
+
+import (
+	"context"
+	"crypto/ecdh"
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/wireguardkeypair"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+// verifyWireGuardKeypair verifies that the public key in data belongs to its private key
+func verifyWireGuardKeypair(t *testing.T, data map[string][]byte) {
+	privateKey, err := base64.StdEncoding.DecodeString(string(data[secret.FieldWireGuardPrivateKey]))
+	require.NoError(t, err)
+	key, err := ecdh.X25519().NewPrivateKey(privateKey)
+	require.NoError(t, err)
+
+	require.Equal(t, base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()), string(data[secret.FieldWireGuardPublicKey]))
+}
+
+func TestGenerateWireGuardKeypairData(t *testing.T) {
+	data := make(map[string][]byte)
+	cons := &secret.WireGuardKeypairConstraints{PresharedKey: true}
+
+	require.NoError(t, secret.GenerateWireGuardKeypairData(logf.Log, cons, false, data))
+	verifyWireGuardKeypair(t, data)
+	presharedKey, err := base64.StdEncoding.DecodeString(string(data[secret.FieldWireGuardPresharedKey]))
+	require.NoError(t, err)
+	require.Len(t, presharedKey, 32)
+
+	privateKey := string(data[secret.FieldWireGuardPrivateKey])
+	publicKey := string(data[secret.FieldWireGuardPublicKey])
+
+	// a missing public key is derived from the existing private key
+	delete(data, secret.FieldWireGuardPublicKey)
+	require.NoError(t, secret.GenerateWireGuardKeypairData(logf.Log, cons, false, data))
+	require.Equal(t, privateKey, string(data[secret.FieldWireGuardPrivateKey]))
+	require.Equal(t, publicKey, string(data[secret.FieldWireGuardPublicKey]))
+
+	require.NoError(t, secret.GenerateWireGuardKeypairData(logf.Log, cons, true, data))
+	verifyWireGuardKeypair(t, data)
+	require.NotEqual(t, privateKey, string(data[secret.FieldWireGuardPrivateKey]))
+
+	data = map[string][]byte{secret.FieldWireGuardPrivateKey: []byte("invalid")}
+	require.Error(t, secret.GenerateWireGuardKeypairData(logf.Log, cons, false, data))
+}
+
+func TestWireGuardKeypairIsGenerated(t *testing.T) {
+	in := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSecretName(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+			Annotations: map[string]string{
+				secret.AnnotationSecretType:            string(secret.TypeWireGuard),
+				secret.AnnotationWireGuardPresharedKey: "true",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{},
+	}
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	verifyWireGuardKeypair(t, out.Data)
+	require.NotEmpty(t, out.Data[secret.FieldWireGuardPresharedKey])
+}
+
+func newWireGuardKeyPairTestCR(spec v1alpha1.WireGuardKeyPairSpec) *v1alpha1.WireGuardKeyPair {
+	return &v1alpha1.WireGuardKeyPair{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiVersion,
+			Kind:       wireguardkeypair.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      uuid.New().String(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+		},
+		Spec: spec,
+	}
+}
+
+func doReconcileWireGuardKeyPairController(t *testing.T, cr *v1alpha1.WireGuardKeyPair, isErr bool) {
+	rec := wireguardkeypair.NewReconciler(mgr)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}}
+
+	res, err := rec.Reconcile(req)
+
+	if isErr {
+		require.Error(t, err)
+	} else {
+		require.NoError(t, err)
+	}
+	require.False(t, res.Requeue)
+}
+
+func TestControllerGenerateWireGuardKeyPair(t *testing.T) {
+	in := newWireGuardKeyPairTestCR(v1alpha1.WireGuardKeyPairSpec{})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileWireGuardKeyPairController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	verifyWireGuardKeypair(t, out.Data)
+	require.NotContains(t, out.Data, secret.FieldWireGuardPresharedKey)
+	privateKey := string(out.Data[secret.FieldWireGuardPrivateKey])
+
+	// the public key is restored if it is removed from the Secret
+	delete(out.Data, secret.FieldWireGuardPublicKey)
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), out))
+
+	doReconcileWireGuardKeyPairController(t, in, false)
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.Equal(t, privateKey, string(out.Data[secret.FieldWireGuardPrivateKey]))
+	verifyWireGuardKeypair(t, out.Data)
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestControllerWireGuardKeyPairFromSpec(t *testing.T) {
+	data := make(map[string][]byte)
+	require.NoError(t, secret.GenerateWireGuardKeypairData(logf.Log, &secret.WireGuardKeypairConstraints{}, false, data))
+
+	in := newWireGuardKeyPairTestCR(v1alpha1.WireGuardKeyPairSpec{PrivateKey: string(data[secret.FieldWireGuardPrivateKey])})
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileWireGuardKeyPairController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.Equal(t, string(data[secret.FieldWireGuardPublicKey]), string(out.Data[secret.FieldWireGuardPublicKey]))
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestWireGuardKeyPairValidateSpec(t *testing.T) {
+	in := newWireGuardKeyPairTestCR(v1alpha1.WireGuardKeyPairSpec{PrivateKey: "invalid"})
+	require.Error(t, wireguardkeypair.ValidateSpec(in))
+
+	in.Spec.PrivateKey = ""
+	require.NoError(t, wireguardkeypair.ValidateSpec(in))
+}
@@ -3,12 +3,16 @@ package secret_test
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -69,14 +73,16 @@ func verifySSHSecretFromCR(t *testing.T, in *v1alpha1.SSHKeyPair, out *corev1.Se
 	}
 
 	// verify validity of private key
-	key, err := secret.PrivateKeyFromPEM(privateKey)
+	key, err := secret.ParsePrivateKeyPEM(privateKey)
 	if err != nil {
 		t.Error(err, "generated private key could not be parsed")
 	}
 
-	err = key.Validate()
-	if err != nil {
-		t.Error(err, "key validation failed")
+	if rsaKey, ok := key.(*rsa.PrivateKey); ok {
+		err = rsaKey.Validate()
+		if err != nil {
+			t.Error(err, "key validation failed")
+		}
 	}
 
 	pub, err := secret.SSHPublicKeyForPrivateKey(key)
@@ -131,6 +137,64 @@ func TestControllerGenerateSSHSecret(t *testing.T) {
 	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
 }
 
+func TestControllerGenerateSSHSecretEd25519(t *testing.T) {
+	testSpec := v1alpha1.SSHKeyPairSpec{
+		Algorithm: string(secret.KeyAlgorithmEd25519),
+		Type:      string(corev1.SecretTypeOpaque),
+		Data:      map[string]string{},
+	}
+	in := newSSHKeyPairTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileSSHKeyPairController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	verifySSHSecretFromCR(t, in, out)
+
+	key, err := secret.ParsePrivateKeyPEM(out.Data[secret.SecretFieldPrivateKey])
+	require.NoError(t, err)
+
+	if _, ok := key.(ed25519.PrivateKey); !ok {
+		t.Errorf("generated private key has wrong type %T", key)
+	}
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestControllerGenerateSSHSecretWithPassphrase(t *testing.T) {
+	testSpec := v1alpha1.SSHKeyPairSpec{
+		Algorithm:  string(secret.KeyAlgorithmEd25519),
+		Passphrase: &v1alpha1.Passphrase{},
+		Type:       string(corev1.SecretTypeOpaque),
+		Data:       map[string]string{},
+	}
+	in := newSSHKeyPairTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileSSHKeyPairController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	passphrase := out.Data[secret.SecretFieldPassphrase]
+	require.NotEmpty(t, passphrase)
+
+	key, err := ssh.ParsePrivateKeyWithPassphrase(out.Data[secret.SecretFieldPrivateKey], passphrase)
+	require.NoError(t, err)
+
+	if !bytes.Equal(out.Data[secret.SecretFieldPublicKey], ssh.MarshalAuthorizedKey(key.PublicKey())) {
+		t.Error("publicKey doesn't match private key")
+	}
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
 func TestControllerRegenerateSSHSecret(t *testing.T) {
 	testSpec := v1alpha1.SSHKeyPairSpec{
 		Length:          "40",
@@ -264,7 +328,7 @@ func TestControllerDoNotRegenerateSSHSecretFixMissingPublicKey(t *testing.T) {
 func TestControllerRegeneratePublicKey(t *testing.T) {
 	data := make(map[string][]byte)
 	var log logr.Logger
-	err := secret.GenerateSSHKeypairData(log, "40", true, data)
+	err := secret.GenerateSSHKeypairData(log, &secret.SSHKeypairConstraints{Length: "40"}, true, data)
 	require.NoError(t, err)
 	testSpec := v1alpha1.SSHKeyPairSpec{
 		Length:          "40",
@@ -329,3 +393,85 @@ func TestControllerDoNotTouchOtherSSHSecrets(t *testing.T) {
 
 	require.NoError(t, mgr.GetClient().Delete(context.TODO(), secret))
 }
+
+func TestControllerRotateSSHKeyPair(t *testing.T) {
+	testSpec := v1alpha1.SSHKeyPairSpec{
+		Algorithm: string(secret.KeyAlgorithmEd25519),
+		Type:      string(corev1.SecretTypeOpaque),
+		Rotation: &v1alpha1.Rotation{
+			Schedule: "* * * * *",
+		},
+	}
+	in := newSSHKeyPairTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileSSHKeyPairController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	// pretend the last rotation happened before the most recent scheduled run
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, in))
+	in.Status.LastRotationTime = &metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+	require.NoError(t, mgr.GetClient().Status().Update(context.TODO(), in))
+
+	doReconcileSSHKeyPairController(t, in, false)
+
+	outNew := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, outNew))
+
+	verifySSHSecretFromCR(t, in, outNew)
+
+	if bytes.Equal(out.Data[secret.SecretFieldPrivateKey], outNew.Data[secret.SecretFieldPrivateKey]) {
+		t.Error("private key has not been rotated")
+	}
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestControllerRotateSSHKeyPairKeepPrevious(t *testing.T) {
+	testSpec := v1alpha1.SSHKeyPairSpec{
+		Algorithm: string(secret.KeyAlgorithmEd25519),
+		Type:      string(corev1.SecretTypeOpaque),
+		Rotation: &v1alpha1.Rotation{
+			Schedule: "* * * * *",
+		},
+		KeepPreviousFor: "1h",
+	}
+	in := newSSHKeyPairTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileSSHKeyPairController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	// pretend the last rotation happened before the most recent scheduled run
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, in))
+	in.Status.LastRotationTime = &metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+	require.NoError(t, mgr.GetClient().Status().Update(context.TODO(), in))
+
+	doReconcileSSHKeyPairController(t, in, false)
+
+	outNew := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, outNew))
+
+	require.NotEqual(t, out.Data[secret.SecretFieldPrivateKey], outNew.Data[secret.SecretFieldPrivateKey])
+	require.Equal(t, out.Data[secret.SecretFieldPrivateKey], outNew.Data[secret.SecretFieldPrivateKey+secret.SecretFieldPreviousSuffix])
+	require.Equal(t, out.Data[secret.SecretFieldPublicKey], outNew.Data[secret.SecretFieldPublicKey+secret.SecretFieldPreviousSuffix])
+	require.Contains(t, outNew.Annotations, secret.AnnotationSecretPreviousExpiry)
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
@@ -1,9 +1,11 @@
 package secret_test
 
 import (
+	"bytes"
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -249,6 +251,296 @@ func TestRegenerateSecretsMultipleFields(t *testing.T) {
 	}
 }
 
+func TestControllerRotateStringSecret(t *testing.T) {
+	testSpec := v1alpha1.StringSecretSpec{
+		Type: string(corev1.SecretTypeOpaque),
+		Fields: []v1alpha1.Field{{
+			FieldName: "test",
+			Length:    "40",
+		}},
+		Rotation: &v1alpha1.Rotation{
+			Schedule: "* * * * *",
+			Timezone: "Europe/Berlin",
+		},
+	}
+	in := newStringSecretTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileStringSecretController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	// pretend the last rotation happened before the most recent scheduled run
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, in))
+	in.Status.LastRotationTime = &metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+	require.NoError(t, mgr.GetClient().Status().Update(context.TODO(), in))
+
+	rec := stringsecret.NewReconciler(mgr)
+	res, err := rec.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: in.Name, Namespace: in.Namespace}})
+	require.NoError(t, err)
+
+	if res.RequeueAfter <= 0 || res.RequeueAfter > time.Minute {
+		t.Errorf("secret is requeued after %s instead of at the next scheduled run", res.RequeueAfter)
+	}
+
+	outNew := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, outNew))
+
+	if bytes.Equal(out.Data["test"], outNew.Data["test"]) {
+		t.Error("field has not been rotated")
+	}
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, in))
+	require.NotNil(t, in.Status.LastRotationTime)
+	require.NotNil(t, in.Status.NextRotationTime)
+	if time.Since(in.Status.LastRotationTime.Time) > time.Minute {
+		t.Error("last rotation time has not been updated")
+	}
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestControllerRotationNotDue(t *testing.T) {
+	testSpec := v1alpha1.StringSecretSpec{
+		Type: string(corev1.SecretTypeOpaque),
+		Fields: []v1alpha1.Field{{
+			FieldName: "test",
+			Length:    "40",
+		}},
+		Rotation: &v1alpha1.Rotation{
+			Schedule: "0 3 1 1 *",
+		},
+	}
+	in := newStringSecretTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileStringSecretController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	rec := stringsecret.NewReconciler(mgr)
+	res, err := rec.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: in.Name, Namespace: in.Namespace}})
+	require.NoError(t, err)
+	require.True(t, res.RequeueAfter > 0)
+
+	outNew := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, outNew))
+
+	if !bytes.Equal(out.Data["test"], outNew.Data["test"]) {
+		t.Error("field has been rotated before the scheduled run")
+	}
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, in))
+	require.Nil(t, in.Status.LastRotationTime)
+	require.NotNil(t, in.Status.NextRotationTime)
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestControllerRotationInvalidSchedule(t *testing.T) {
+	testSpec := v1alpha1.StringSecretSpec{
+		Type: string(corev1.SecretTypeOpaque),
+		Fields: []v1alpha1.Field{{
+			FieldName: "test",
+			Length:    "40",
+		}},
+		Rotation: &v1alpha1.Rotation{
+			Schedule: "every sunday",
+		},
+	}
+	in := newStringSecretTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileStringSecretController(t, in, true)
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestControllerKeepPreviousValue(t *testing.T) {
+	testSpec := v1alpha1.StringSecretSpec{
+		Type: string(corev1.SecretTypeOpaque),
+		Fields: []v1alpha1.Field{{
+			FieldName: "test",
+			Length:    "40",
+		}},
+		KeepPreviousFor: "1h",
+	}
+	in := newStringSecretTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileStringSecretController(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	require.NotContains(t, out.Data, "test"+secret.SecretFieldPreviousSuffix)
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, in))
+	in.Spec.ForceRegenerate = true
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), in))
+
+	doReconcileStringSecretController(t, in, false)
+
+	outNew := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, outNew))
+
+	require.NotEqual(t, out.Data["test"], outNew.Data["test"])
+	require.Equal(t, out.Data["test"], outNew.Data["test"+secret.SecretFieldPreviousSuffix])
+	require.Contains(t, outNew.Annotations, secret.AnnotationSecretPreviousExpiry)
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+// getStringSecretTestCR fetches the current state of the given StringSecret CR
+func getStringSecretTestCR(t *testing.T, in *v1alpha1.StringSecret) *v1alpha1.StringSecret {
+	out := &v1alpha1.StringSecret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      in.Name,
+		Namespace: in.Namespace}, out))
+
+	return out
+}
+
+// requireCondition checks that status has a condition of the given type with the given status
+func requireCondition(t *testing.T, status v1alpha1.SecretStatus, conditionType v1alpha1.ConditionType, conditionStatus corev1.ConditionStatus) {
+	condition := crd.GetCondition(status, conditionType)
+	require.NotNil(t, condition, "missing condition %s", conditionType)
+	require.Equal(t, conditionStatus, condition.Status, "condition %s", conditionType)
+}
+
+func TestControllerStatusReady(t *testing.T) {
+	testSpec := v1alpha1.StringSecretSpec{
+		Type: string(corev1.SecretTypeOpaque),
+		Fields: []v1alpha1.Field{{
+			FieldName: "test",
+			Length:    "40",
+		}},
+	}
+	in := newStringSecretTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileStringSecretController(t, in, false)
+
+	out := getStringSecretTestCR(t, in)
+	requireCondition(t, &out.Status, v1alpha1.ConditionReady, corev1.ConditionTrue)
+	requireCondition(t, &out.Status, v1alpha1.ConditionInvalidSpec, corev1.ConditionFalse)
+	requireCondition(t, &out.Status, v1alpha1.ConditionSecretOwnershipConflict, corev1.ConditionFalse)
+	require.Equal(t, out.Generation, out.Status.ObservedGeneration)
+	require.Equal(t, []string{"test"}, out.Status.GeneratedFields)
+	require.NotNil(t, out.Status.LastGeneratedTime)
+	generatedTime := out.Status.LastGeneratedTime
+
+	// values are not regenerated, so the generation time must not change
+	doReconcileStringSecretController(t, in, false)
+
+	out = getStringSecretTestCR(t, in)
+	require.Equal(t, generatedTime.Unix(), out.Status.LastGeneratedTime.Unix())
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestControllerStatusInvalidSpec(t *testing.T) {
+	testSpec := v1alpha1.StringSecretSpec{
+		Type: string(corev1.SecretTypeOpaque),
+		Fields: []v1alpha1.Field{{
+			FieldName: "test",
+			Length:    "forty",
+		}},
+	}
+	in := newStringSecretTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileStringSecretController(t, in, true)
+
+	out := getStringSecretTestCR(t, in)
+	requireCondition(t, &out.Status, v1alpha1.ConditionReady, corev1.ConditionFalse)
+	requireCondition(t, &out.Status, v1alpha1.ConditionInvalidSpec, corev1.ConditionTrue)
+	require.Equal(t, out.Generation, out.Status.ObservedGeneration)
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
+func TestStringSecretValidateSpec(t *testing.T) {
+	valid := newStringSecretTestCR(v1alpha1.StringSecretSpec{
+		Fields: []v1alpha1.Field{
+			{FieldName: "test", Length: "20", Encoding: "hex"},
+			{FieldName: "other"},
+			{FieldName: "charset", Charset: &v1alpha1.Charset{MinDigits: 2, ExcludeAmbiguous: true}},
+			{FieldName: "passphrase", Passphrase: &v1alpha1.WordPassphrase{Words: 4, Capitalize: true}},
+		},
+	}, "")
+	require.NoError(t, stringsecret.ValidateSpec(valid))
+
+	invalid := map[string][]v1alpha1.Field{
+		"duplicate field name":     {{FieldName: "test"}, {FieldName: "test"}},
+		"invalid length":           {{FieldName: "test", Length: "forty"}},
+		"unknown encoding":         {{FieldName: "test", Encoding: "base58"}},
+		"charset with encoding":    {{FieldName: "test", Encoding: "hex", Charset: &v1alpha1.Charset{}}},
+		"unsatisfiable charset":    {{FieldName: "test", Length: "4", Charset: &v1alpha1.Charset{MinDigits: 5}}},
+		"passphrase with encoding": {{FieldName: "test", Encoding: "hex", Passphrase: &v1alpha1.WordPassphrase{}}},
+		"too many words":           {{FieldName: "test", Passphrase: &v1alpha1.WordPassphrase{Words: 1000}}},
+	}
+
+	for name, fields := range invalid {
+		in := newStringSecretTestCR(v1alpha1.StringSecretSpec{Fields: fields}, "")
+		require.Error(t, stringsecret.ValidateSpec(in), name)
+	}
+}
+
+func TestControllerStatusOwnershipConflict(t *testing.T) {
+	existing := &corev1.Secret{
+		Type: corev1.SecretTypeOpaque,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      uuid.New().String(),
+			Namespace: "default",
+			Labels: map[string]string{
+				labelSecretGeneratorTest: "yes",
+			},
+		},
+	}
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), existing))
+
+	testSpec := v1alpha1.StringSecretSpec{
+		Type: string(corev1.SecretTypeOpaque),
+		Fields: []v1alpha1.Field{{
+			FieldName: "test",
+			Length:    "40",
+		}},
+	}
+	in := newStringSecretTestCR(testSpec, existing.Name)
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcileStringSecretController(t, in, false)
+
+	out := getStringSecretTestCR(t, in)
+	requireCondition(t, &out.Status, v1alpha1.ConditionReady, corev1.ConditionFalse)
+	requireCondition(t, &out.Status, v1alpha1.ConditionSecretOwnershipConflict, corev1.ConditionTrue)
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
+
 func TestDoNotTouchOtherSecrets(t *testing.T) {
 	secret := &corev1.Secret{
 		Type: corev1.SecretTypeOpaque,
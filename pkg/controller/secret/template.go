@@ -0,0 +1,110 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// templateFuncs are the functions available in templates in addition to the builtin functions of text/template
+var templateFuncs = template.FuncMap{
+	// userinfo escapes username and password for the userinfo of an url, e.g. postgres://{{ userinfo "app" .password }}@db
+	"userinfo": func(username, password string) string {
+		return url.UserPassword(username, password).String()
+	},
+	"base64": func(value string) string {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	},
+}
+
+// parseTemplate parses the template rendering key
+func parseTemplate(key, text string) (*template.Template, error) {
+	if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+		return nil, fmt.Errorf("%s is not a valid key: %s", key, strings.Join(errs, ", "))
+	}
+
+	tmpl, err := template.New(key).Option("missingkey=error").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return tmpl, nil
+}
+
+// ValidateTemplates checks whether templates, which map keys to the templates rendering them, can be parsed.
+// Templates must not render the keys in reserved, e.g. generated keys, as those would be overwritten.
+func ValidateTemplates(templates map[string]string, reserved []string) error {
+	for key, text := range templates {
+		if contains(reserved, key) {
+			return fmt.Errorf("key %s is rendered by a template and must not be set otherwise", key)
+		}
+		if _, err := parseTemplate(key, text); err != nil {
+			return errors.Wrapf(err, "invalid template of key %s", key)
+		}
+	}
+
+	return nil
+}
+
+// RenderTemplates renders templates, which map keys to the templates rendering them, into data. The templates
+// are executed with the other keys of data, e.g. {{ .password }} or {{ index . "api-key" }}. As they are
+// rendered on every reconciliation, they always reflect the current values, e.g. after regeneration.
+func RenderTemplates(templates map[string]string, data map[string][]byte) error {
+	if len(templates) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(data))
+	for key, value := range data {
+		if _, ok := templates[key]; !ok {
+			values[key] = string(value)
+		}
+	}
+
+	keys := make([]string, 0, len(templates))
+	for key := range templates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rendered := make(map[string][]byte, len(templates))
+	for _, key := range keys {
+		tmpl, err := parseTemplate(key, templates[key])
+		if err != nil {
+			return errors.Wrapf(err, "invalid template of key %s", key)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, values); err != nil {
+			return errors.Wrapf(err, "could not render template of key %s", key)
+		}
+		rendered[key] = buf.Bytes()
+	}
+
+	// keys are only changed if all templates could be rendered
+	for key, value := range rendered {
+		data[key] = value
+	}
+
+	return nil
+}
+
+// GetTemplatesFromAnnotations returns the templates configured by annotations prefixed with AnnotationTemplatePrefix,
+// mapping the keys following the prefix to their templates
+func GetTemplatesFromAnnotations(annotations map[string]string) map[string]string {
+	templates := make(map[string]string)
+	for name, value := range annotations {
+		if strings.HasPrefix(name, AnnotationTemplatePrefix) {
+			templates[strings.TrimPrefix(name, AnnotationTemplatePrefix)] = value
+		}
+	}
+
+	return templates
+}
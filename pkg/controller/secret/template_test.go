@@ -0,0 +1,103 @@
+package secret_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+const testDatabaseURLTemplate = `postgres://{{ userinfo "app" .password }}@db:5432/app`
+
+func TestRenderTemplates(t *testing.T) {
+	data := map[string][]byte{
+		"password": []byte("p@ss/word"),
+		"api-key":  []byte("key"),
+	}
+
+	require.NoError(t, secret.RenderTemplates(map[string]string{
+		"DATABASE_URL": testDatabaseURLTemplate,
+		"header":       `Bearer {{ index . "api-key" }}`,
+	}, data))
+
+	require.Equal(t, "postgres://app:p%40ss%2Fword@db:5432/app", string(data["DATABASE_URL"]))
+	require.Equal(t, "Bearer key", string(data["header"]))
+}
+
+func TestRenderTemplatesMissingKey(t *testing.T) {
+	data := map[string][]byte{}
+
+	require.Error(t, secret.RenderTemplates(map[string]string{"url": "{{ .password }}"}, data))
+	require.NotContains(t, data, "url")
+}
+
+func TestValidateTemplateAnnotations(t *testing.T) {
+	require.NoError(t, secret.ValidateAnnotations(map[string]string{
+		secret.AnnotationSecretAutoGenerate:              "password",
+		secret.AnnotationTemplatePrefix + "DATABASE_URL": testDatabaseURLTemplate,
+	}))
+
+	require.Error(t, secret.ValidateAnnotations(map[string]string{
+		secret.AnnotationSecretAutoGenerate:              "password",
+		secret.AnnotationTemplatePrefix + "DATABASE_URL": "{{ .password ",
+	}))
+
+	require.Error(t, secret.ValidateAnnotations(map[string]string{
+		secret.AnnotationSecretAutoGenerate:          "password",
+		secret.AnnotationTemplatePrefix + "password": "{{ .password }}",
+	}))
+}
+
+func TestTemplatesAreRenderedAfterRegeneration(t *testing.T) {
+	in := newStringTestSecret("password", map[string]string{
+		secret.AnnotationTemplatePrefix + "DATABASE_URL": `postgres://app:{{ .password }}@db:5432/app`,
+	}, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	doReconcile(t, in, false)
+
+	out := &corev1.Secret{}
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.Equal(t, "postgres://app:"+string(out.Data["password"])+"@db:5432/app", string(out.Data["DATABASE_URL"]))
+
+	previous := string(out.Data["password"])
+	out.Annotations[secret.AnnotationSecretRegenerate] = "yes"
+	require.NoError(t, mgr.GetClient().Update(context.TODO(), out))
+
+	doReconcile(t, out, false)
+
+	require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+	require.NotEqual(t, previous, string(out.Data["password"]))
+	require.Equal(t, "postgres://app:"+string(out.Data["password"])+"@db:5432/app", string(out.Data["DATABASE_URL"]))
+}
+
+func TestControllerRenderTemplates(t *testing.T) {
+	testSpec := v1alpha1.StringSecretSpec{
+		Fields: []v1alpha1.Field{{
+			FieldName: "password",
+			Encoding:  "hex",
+		}},
+		Templates: map[string]string{
+			"DATABASE_URL": testDatabaseURLTemplate,
+		},
+		ForceRegenerate: true,
+	}
+	in := newStringSecretTestCR(testSpec, "")
+	require.NoError(t, mgr.GetClient().Create(context.TODO(), in))
+
+	for i := 0; i < 2; i++ {
+		// values are regenerated on each reconciliation, so the template has to be rendered again
+		doReconcileStringSecretController(t, in, false)
+
+		out := &corev1.Secret{}
+		require.NoError(t, mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: in.Name, Namespace: in.Namespace}, out))
+		require.Equal(t, "postgres://app:"+string(out.Data["password"])+"@db:5432/app", string(out.Data["DATABASE_URL"]))
+	}
+
+	require.NoError(t, mgr.GetClient().Delete(context.TODO(), in))
+}
@@ -18,22 +18,73 @@ const (
 	AnnotationSecretType            = "secret-generator.v1.mittwald.de/type"
 	AnnotationSecretLength          = "secret-generator.v1.mittwald.de/length"
 	AnnotationBasicAuthUsername     = "secret-generator.v1.mittwald.de/basic-auth-username"
+	AnnotationBasicAuthAlgorithm    = "secret-generator.v1.mittwald.de/basic-auth-hash-algorithm"
+	AnnotationBasicAuthCost         = "secret-generator.v1.mittwald.de/basic-auth-cost"
+	AnnotationDockerRegistryServer  = "secret-generator.v1.mittwald.de/docker-registry-server"
+	AnnotationDockerRegistryUser    = "secret-generator.v1.mittwald.de/docker-registry-username"
 	AnnotationSecretEncoding        = "secret-generator.v1.mittwald.de/encoding"
+	AnnotationCharset               = "secret-generator.v1.mittwald.de/charset"
+	AnnotationCharsetExclude        = "secret-generator.v1.mittwald.de/charset-exclude"
+	AnnotationCharsetNoAmbiguous    = "secret-generator.v1.mittwald.de/charset-exclude-ambiguous"
+	AnnotationCharsetMinCounts      = "secret-generator.v1.mittwald.de/charset-min-counts"
+	AnnotationPassphraseWords       = "secret-generator.v1.mittwald.de/passphrase-words"
+	AnnotationPassphraseSeparator   = "secret-generator.v1.mittwald.de/passphrase-separator"
+	AnnotationPassphraseCapitalize  = "secret-generator.v1.mittwald.de/passphrase-capitalize"
+	AnnotationPassphraseDigit       = "secret-generator.v1.mittwald.de/passphrase-digit"
+	AnnotationSecretRotateAfter     = "secret-generator.v1.mittwald.de/rotate-after"
+	AnnotationSecretHashes          = "secret-generator.v1.mittwald.de/hashes"
+	AnnotationSecretKeepPrevious    = "secret-generator.v1.mittwald.de/keep-previous-for"
+	AnnotationSecretPreviousExpiry  = "secret-generator.v1.mittwald.de/previous-expires-at"
+	AnnotationRolloutOnChange       = "secret-generator.v1.mittwald.de/rollout-on-change"
+	AnnotationRestartedAt           = "secret-generator.v1.mittwald.de/restarted-at"
+	AnnotationKeyAlgorithm          = "secret-generator.v1.mittwald.de/key-algorithm"
+	AnnotationPrivateKeyFormat      = "secret-generator.v1.mittwald.de/private-key-format"
+	AnnotationPassphraseField       = "secret-generator.v1.mittwald.de/passphrase-field"
+	AnnotationPassphraseSecret      = "secret-generator.v1.mittwald.de/passphrase-secret"
+	AnnotationTLSCommonName         = "secret-generator.v1.mittwald.de/tls-common-name"
+	AnnotationTLSSubjectAltNames    = "secret-generator.v1.mittwald.de/tls-subject-alt-names"
+	AnnotationTLSValidity           = "secret-generator.v1.mittwald.de/tls-validity"
+	AnnotationTLSRenewBefore        = "secret-generator.v1.mittwald.de/tls-renew-before"
+	AnnotationTLSReusePrivateKey    = "secret-generator.v1.mittwald.de/tls-reuse-private-key"
+	AnnotationWireGuardPresharedKey = "secret-generator.v1.mittwald.de/wireguard-preshared-key"
+	AnnotationJWTAlgorithm          = "secret-generator.v1.mittwald.de/jwt-algorithm"
+	AnnotationTOTPIssuer            = "secret-generator.v1.mittwald.de/totp-issuer"
+	AnnotationTOTPAccount           = "secret-generator.v1.mittwald.de/totp-account"
+	AnnotationTOTPDigits            = "secret-generator.v1.mittwald.de/totp-digits"
+	AnnotationTOTPPeriod            = "secret-generator.v1.mittwald.de/totp-period"
+	AnnotationTOTPQRCode            = "secret-generator.v1.mittwald.de/totp-qr-code"
+	AnnotationPGPUserID             = "secret-generator.v1.mittwald.de/pgp-user-id"
+	AnnotationPGPExpiry             = "secret-generator.v1.mittwald.de/pgp-expiry"
+	AnnotationTemplatePrefix        = "secret-generator.v1.mittwald.de/template."
 )
 
 type Type string
 
 const (
-	TypeString     Type = "string"
-	TypeSSHKeypair Type = "ssh-keypair"
-	TypeBasicAuth  Type = "basic-auth"
+	TypeString         Type = "string"
+	TypeSSHKeypair     Type = "ssh-keypair"
+	TypeBasicAuth      Type = "basic-auth"
+	TypeTLS            Type = "tls"
+	TypeDockerRegistry Type = "docker-registry"
+	TypeWireGuard      Type = "wireguard-keypair"
+	TypeJWT            Type = "jwt-signing-key"
+	TypeTOTP           Type = "totp"
+	TypePGPKeypair     Type = "pgp-keypair"
+	TypeAgeKeypair     Type = "age-keypair"
 )
 
 func (st Type) Validate() error {
 	switch st {
 	case TypeString,
 		TypeSSHKeypair,
-		TypeBasicAuth:
+		TypeBasicAuth,
+		TypeTLS,
+		TypeDockerRegistry,
+		TypeWireGuard,
+		TypeJWT,
+		TypeTOTP,
+		TypePGPKeypair,
+		TypeAgeKeypair:
 		return nil
 	}
 	return fmt.Errorf("%s is not a valid secret type", st)
@@ -43,6 +94,13 @@ type Generator interface {
 	generateData(*corev1.Secret) (reconcile.Result, error)
 }
 
+// previousValuesPruner is implemented by generators which keep previous values somewhere else than in the
+// companion keys of generated keys
+type previousValuesPruner interface {
+	// prunePreviousValues removes the previous values kept in data
+	prunePreviousValues(data map[string][]byte)
+}
+
 // ParseByteLength parses the given length string into an integer length and determines whether the byte-length-suffix was set.
 // In case paring fails, or the string is empty, the fallback will be returned, along with false.
 func ParseByteLength(fallback int, length string) (int, bool, error) {
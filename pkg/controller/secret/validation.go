@@ -0,0 +1,209 @@
+package secret
+
+import (
+	"crypto/x509"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// annotationValidators check the values of the annotations configuring the generation of a Secret
+var annotationValidators = map[string]func(value string) error{
+	AnnotationSecretAutoGenerate: func(value string) error {
+		return EnsureUniqueness(strings.Split(value, ","))
+	},
+	AnnotationSecretType: func(value string) error {
+		// an empty type defaults to strings
+		if value == "" {
+			return nil
+		}
+		return Type(value).Validate()
+	},
+	AnnotationSecretEncoding: ValidateEncoding,
+	AnnotationSecretHashes: func(value string) error {
+		_, err := ParseDerivedHashes(value)
+		return err
+	},
+	AnnotationCharsetNoAmbiguous: func(value string) error {
+		_, err := strconv.ParseBool(value)
+		return err
+	},
+	AnnotationCharsetMinCounts: func(value string) error {
+		return parseCharsetMinCounts(&CharsetConstraints{}, value)
+	},
+	AnnotationPassphraseWords: func(value string) error {
+		_, err := parsePassphraseWords(value)
+		return err
+	},
+	AnnotationPassphraseCapitalize: func(value string) error {
+		_, err := strconv.ParseBool(value)
+		return err
+	},
+	AnnotationPassphraseDigit: func(value string) error {
+		_, err := strconv.ParseBool(value)
+		return err
+	},
+	AnnotationSecretLength: func(value string) error {
+		_, _, err := ParseByteLength(DefaultLength(), value)
+		return err
+	},
+	AnnotationSecretRotateAfter: func(value string) error {
+		_, err := parseRotateAfter(value)
+		return err
+	},
+	AnnotationSecretKeepPrevious: func(value string) error {
+		_, err := ParseGracePeriod(value)
+		return err
+	},
+	AnnotationBasicAuthAlgorithm: func(value string) error {
+		return HashAlgorithm(value).Validate()
+	},
+	AnnotationBasicAuthCost: func(value string) error {
+		cost, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		return ValidateHashCost(HashAlgorithmBcrypt, cost)
+	},
+	AnnotationKeyAlgorithm: func(value string) error {
+		return KeyAlgorithm(value).Validate()
+	},
+	AnnotationPrivateKeyFormat: func(value string) error {
+		return PrivateKeyFormat(value).Validate()
+	},
+	AnnotationWireGuardPresharedKey: func(value string) error {
+		_, err := strconv.ParseBool(value)
+		return err
+	},
+	AnnotationJWTAlgorithm: func(value string) error {
+		return JWTAlgorithm(value).Validate()
+	},
+	AnnotationTOTPIssuer:  validateTOTPLabel,
+	AnnotationTOTPAccount: validateTOTPLabel,
+	AnnotationTOTPDigits: func(value string) error {
+		_, err := parseTOTPDigits(value)
+		return err
+	},
+	AnnotationTOTPPeriod: func(value string) error {
+		_, err := parseTOTPPeriod(value)
+		return err
+	},
+	AnnotationTOTPQRCode: func(value string) error {
+		_, err := strconv.ParseBool(value)
+		return err
+	},
+	AnnotationPGPUserID: func(value string) error {
+		_, _, _, err := parsePGPUserID(value)
+		return err
+	},
+	AnnotationPGPExpiry: func(value string) error {
+		_, err := parsePGPExpiry(value)
+		return err
+	},
+	AnnotationTLSSubjectAltNames: func(value string) error {
+		return addSubjectAltNames(&x509.Certificate{}, strings.Split(value, ","))
+	},
+	AnnotationTLSValidity: func(value string) error {
+		_, err := parseValidity(&TLSConstraints{Validity: value}, DefaultTLSValidity)
+		return err
+	},
+	AnnotationTLSRenewBefore: func(value string) error {
+		_, err := parseRenewBefore(&TLSConstraints{RenewBefore: value})
+		return err
+	},
+}
+
+// ValidateAnnotations checks the values of the annotations configuring the generation of a Secret.
+// The returned error names the first invalid annotation.
+func ValidateAnnotations(annotations map[string]string) error {
+	names := make([]string, 0, len(annotations))
+	for name := range annotations {
+		if _, ok := annotationValidators[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := annotationValidators[name](annotations[name]); err != nil {
+			return errors.Wrapf(err, "invalid value for annotation %s", name)
+		}
+	}
+
+	if annotations[AnnotationSecretEncoding] == EncodingCharset {
+		if err := validateCharsetAnnotations(annotations); err != nil {
+			return err
+		}
+	}
+
+	if algorithm, cost, err := getHashFromAnnotations(annotations); err == nil && algorithm != "" {
+		if err := ValidateHashCost(algorithm, cost); err != nil {
+			return errors.Wrapf(err, "invalid value for annotation %s", AnnotationBasicAuthCost)
+		}
+	}
+
+	// unknown types were rejected above, secrets without a type default to strings like in secretType
+	sType := Type(annotations[AnnotationSecretType])
+	if sType == "" {
+		sType = TypeString
+	}
+
+	if sType == TypeDockerRegistry && annotations[AnnotationDockerRegistryServer] == "" {
+		return errors.Errorf("annotation %s is required for type %s", AnnotationDockerRegistryServer, TypeDockerRegistry)
+	}
+
+	if sType == TypeSSHKeypair {
+		cons := &SSHKeypairConstraints{
+			Length:    annotations[AnnotationSecretLength],
+			Algorithm: annotations[AnnotationKeyAlgorithm],
+			Format:    annotations[AnnotationPrivateKeyFormat],
+		}
+		if err := cons.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid key pair annotations for type %s", TypeSSHKeypair)
+		}
+	}
+
+	if sType == TypeTLS {
+		if err := validateTLSPrivateKeyFormat(annotations[AnnotationPrivateKeyFormat]); err != nil {
+			return errors.Wrapf(err, "invalid value for annotation %s", AnnotationPrivateKeyFormat)
+		}
+
+		cons := &TLSConstraints{
+			Algorithm: annotations[AnnotationKeyAlgorithm],
+			Length:    annotations[AnnotationSecretLength],
+		}
+		if err := cons.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid certificate annotations for type %s", TypeTLS)
+		}
+	}
+
+	if sType == TypeJWT {
+		cons := &JWTConstraints{
+			Algorithm: annotations[AnnotationJWTAlgorithm],
+			Length:    annotations[AnnotationSecretLength],
+		}
+		if err := cons.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid signing key annotations for type %s", TypeJWT)
+		}
+	}
+
+	return ValidateTemplates(GetTemplatesFromAnnotations(annotations), generatedKeys(sType, annotations))
+}
+
+// validateCharsetAnnotations checks whether strings of the configured length can be generated with the
+// charset constraints configured by annotations
+func validateCharsetAnnotations(annotations map[string]string) error {
+	cons, err := GetCharsetConstraintsFromAnnotations(annotations)
+	if err != nil {
+		return err
+	}
+
+	length, _, err := ParseByteLength(DefaultLength(), annotations[AnnotationSecretLength])
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(cons.Validate(length), "invalid charset constraints")
+}
@@ -0,0 +1,87 @@
+package secret_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+func TestValidateAnnotations(t *testing.T) {
+	require.NoError(t, secret.ValidateAnnotations(map[string]string{
+		secret.AnnotationSecretAutoGenerate: "password,token",
+		secret.AnnotationSecretLength:       "32b",
+		secret.AnnotationSecretType:         string(secret.TypeString),
+		secret.AnnotationSecretEncoding:     "base32",
+		secret.AnnotationSecretRotateAfter:  "24h",
+		secret.AnnotationKeyAlgorithm:       string(secret.KeyAlgorithmEd25519),
+		secret.AnnotationTLSValidity:        "48h",
+		"unrelated":                         "value",
+	}))
+
+	invalid := map[string]string{
+		secret.AnnotationSecretAutoGenerate: "password,password",
+		secret.AnnotationSecretLength:       "forty",
+		secret.AnnotationSecretType:         "password",
+		secret.AnnotationSecretEncoding:     "base58",
+		secret.AnnotationSecretRotateAfter:  "-1h",
+		secret.AnnotationSecretKeepPrevious: "a day",
+		secret.AnnotationKeyAlgorithm:       "dsa",
+		secret.AnnotationPrivateKeyFormat:   "ppk",
+		secret.AnnotationTLSValidity:        "one year",
+		secret.AnnotationTLSRenewBefore:     "-24h",
+	}
+
+	for annotation, value := range invalid {
+		err := secret.ValidateAnnotations(map[string]string{annotation: value})
+		require.Error(t, err, annotation)
+		require.Contains(t, err.Error(), annotation)
+	}
+}
+
+func TestValidateAnnotationsRejectsOpenSSHFormatForTLS(t *testing.T) {
+	annotations := map[string]string{
+		secret.AnnotationSecretType:       string(secret.TypeTLS),
+		secret.AnnotationPrivateKeyFormat: string(secret.PrivateKeyFormatPKCS1),
+	}
+	require.NoError(t, secret.ValidateAnnotations(annotations))
+
+	annotations[secret.AnnotationPrivateKeyFormat] = string(secret.PrivateKeyFormatOpenSSH)
+	err := secret.ValidateAnnotations(annotations)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), secret.AnnotationPrivateKeyFormat)
+
+	// the OpenSSH format is still valid for SSH keys
+	annotations[secret.AnnotationSecretType] = string(secret.TypeSSHKeypair)
+	require.NoError(t, secret.ValidateAnnotations(annotations))
+}
+
+func TestValidateAnnotationsRejectsShortRSAKeysForTLS(t *testing.T) {
+	annotations := map[string]string{
+		secret.AnnotationSecretType:   string(secret.TypeTLS),
+		secret.AnnotationKeyAlgorithm: string(secret.KeyAlgorithmRSA),
+		secret.AnnotationSecretLength: "1024",
+	}
+	require.Error(t, secret.ValidateAnnotations(annotations))
+
+	annotations[secret.AnnotationSecretLength] = "256b"
+	require.Error(t, secret.ValidateAnnotations(annotations))
+
+	annotations[secret.AnnotationSecretLength] = "2048"
+	require.NoError(t, secret.ValidateAnnotations(annotations))
+}
+
+func TestValidateAnnotationsRejectsShortRSAKeysForJWT(t *testing.T) {
+	annotations := map[string]string{
+		secret.AnnotationSecretType:   string(secret.TypeJWT),
+		secret.AnnotationSecretLength: "1024",
+	}
+	require.Error(t, secret.ValidateAnnotations(annotations))
+
+	annotations[secret.AnnotationSecretLength] = "256b"
+	require.Error(t, secret.ValidateAnnotations(annotations))
+
+	annotations[secret.AnnotationSecretLength] = "2048"
+	require.NoError(t, secret.ValidateAnnotations(annotations))
+}
@@ -0,0 +1,10 @@
+package webhook
+
+import (
+	"github.com/mittwald/kubernetes-secret-generator/pkg/webhook/secretmutator"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create webhooks and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, secretmutator.Add)
+}
@@ -0,0 +1,111 @@
+package crvalidator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/basicauth"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/certificate"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/certificateauthority"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/dockerregistry"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/pgpkeypair"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/sshkeypair"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/stringsecret"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/wireguardkeypair"
+)
+
+// Path is the path the webhook is served at
+const Path = "/validate-secretgenerator-v1alpha1"
+
+// validator decodes the cr of an admission request and checks its spec
+type validator struct {
+	newObject    func() runtime.Object
+	validateSpec func(runtime.Object) error
+}
+
+// validators are the validators of the crs by kind, using the same checks as the controllers of the crs
+var validators = map[string]validator{
+	stringsecret.Kind: {
+		newObject:    func() runtime.Object { return &v1alpha1.StringSecret{} },
+		validateSpec: func(o runtime.Object) error { return stringsecret.ValidateSpec(o.(*v1alpha1.StringSecret)) },
+	},
+	sshkeypair.Kind: {
+		newObject:    func() runtime.Object { return &v1alpha1.SSHKeyPair{} },
+		validateSpec: func(o runtime.Object) error { return sshkeypair.ValidateSpec(o.(*v1alpha1.SSHKeyPair)) },
+	},
+	basicauth.Kind: {
+		newObject:    func() runtime.Object { return &v1alpha1.BasicAuth{} },
+		validateSpec: func(o runtime.Object) error { return basicauth.ValidateSpec(o.(*v1alpha1.BasicAuth)) },
+	},
+	certificateauthority.Kind: {
+		newObject: func() runtime.Object { return &v1alpha1.CertificateAuthority{} },
+		validateSpec: func(o runtime.Object) error {
+			return certificateauthority.ValidateSpec(o.(*v1alpha1.CertificateAuthority))
+		},
+	},
+	certificate.Kind: {
+		newObject:    func() runtime.Object { return &v1alpha1.Certificate{} },
+		validateSpec: func(o runtime.Object) error { return certificate.ValidateSpec(o.(*v1alpha1.Certificate)) },
+	},
+	dockerregistry.Kind: {
+		newObject:    func() runtime.Object { return &v1alpha1.DockerRegistry{} },
+		validateSpec: func(o runtime.Object) error { return dockerregistry.ValidateSpec(o.(*v1alpha1.DockerRegistry)) },
+	},
+	wireguardkeypair.Kind: {
+		newObject: func() runtime.Object { return &v1alpha1.WireGuardKeyPair{} },
+		validateSpec: func(o runtime.Object) error {
+			return wireguardkeypair.ValidateSpec(o.(*v1alpha1.WireGuardKeyPair))
+		},
+	},
+	pgpkeypair.Kind: {
+		newObject:    func() runtime.Object { return &v1alpha1.PGPKeyPair{} },
+		validateSpec: func(o runtime.Object) error { return pgpkeypair.ValidateSpec(o.(*v1alpha1.PGPKeyPair)) },
+	},
+}
+
+// Add creates a new CRValidator and registers it with the webhook server of the Manager
+func Add(mgr manager.Manager) error {
+	mgr.GetWebhookServer().Register(Path, &webhook.Admission{Handler: &CRValidator{}})
+	return nil
+}
+
+// CRValidator rejects crs with invalid specs, so that they are not only detected by their controllers
+type CRValidator struct {
+	decoder *admission.Decoder
+}
+
+// blank assignments to verify that CRValidator implements admission.Handler and admission.DecoderInjector
+var _ admission.Handler = &CRValidator{}
+var _ admission.DecoderInjector = &CRValidator{}
+
+// InjectDecoder sets the decoder used to decode the crs of admission requests
+func (v *CRValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle denies the cr in req, if its spec is invalid
+func (v *CRValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	val, ok := validators[req.Kind.Kind]
+	if !ok {
+		return admission.Errored(http.StatusBadRequest, fmt.Errorf("unsupported kind %s", req.Kind.Kind))
+	}
+
+	instance := val.newObject()
+	if err := v.decoder.Decode(req, instance); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if err := val.validateSpec(instance); err != nil {
+		return admission.Denied(fmt.Sprintf("invalid spec of %s: %s", req.Kind.Kind, err))
+	}
+
+	return admission.Allowed("")
+}
@@ -0,0 +1,140 @@
+package crvalidator_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/apis/secretgenerator/v1alpha1"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/dockerregistry"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/pgpkeypair"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/crd/stringsecret"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/webhook/crvalidator"
+)
+
+// newCRValidator returns a CRValidator with an injected decoder, like the webhook server does
+func newCRValidator(t *testing.T) *crvalidator.CRValidator {
+	require.NoError(t, apis.AddToScheme(scheme.Scheme))
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	require.NoError(t, err)
+
+	v := &crvalidator.CRValidator{}
+	require.NoError(t, v.InjectDecoder(decoder))
+
+	return v
+}
+
+// newCRRequest returns a request creating the given cr of kind
+func newCRRequest(t *testing.T, kind string, cr runtime.Object) admission.Request {
+	raw, err := json.Marshal(cr)
+	require.NoError(t, err)
+
+	return admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: v1alpha1.SchemeGroupVersion.Group, Version: v1alpha1.SchemeGroupVersion.Version, Kind: kind},
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+func newStringSecret(fields ...v1alpha1.Field) *v1alpha1.StringSecret {
+	return &v1alpha1.StringSecret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       stringsecret.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec:       v1alpha1.StringSecretSpec{Fields: fields},
+	}
+}
+
+func TestCRValidatorStringSecret(t *testing.T) {
+	v := newCRValidator(t)
+
+	res := v.Handle(context.TODO(), newCRRequest(t, stringsecret.Kind, newStringSecret(
+		v1alpha1.Field{FieldName: "password", Length: "32", Encoding: "base32"},
+		v1alpha1.Field{FieldName: "token"},
+	)))
+	require.True(t, res.Allowed, "%v", res.Result)
+
+	denied := map[string]struct {
+		cr      *v1alpha1.StringSecret
+		message string
+	}{
+		"invalid length": {
+			cr:      newStringSecret(v1alpha1.Field{FieldName: "password", Length: "abc"}),
+			message: "invalid spec of StringSecret: invalid length of field password",
+		},
+		"unknown encoding": {
+			cr:      newStringSecret(v1alpha1.Field{FieldName: "password", Encoding: "base58"}),
+			message: "invalid spec of StringSecret: invalid encoding of field password: base58 is not a valid encoding",
+		},
+		"duplicate field names": {
+			cr:      newStringSecret(v1alpha1.Field{FieldName: "password"}, v1alpha1.Field{FieldName: "password"}),
+			message: "invalid spec of StringSecret: invalid fields",
+		},
+	}
+
+	for name, test := range denied {
+		res := v.Handle(context.TODO(), newCRRequest(t, stringsecret.Kind, test.cr))
+		require.False(t, res.Allowed, name)
+		require.Contains(t, string(res.Result.Reason), test.message, name)
+	}
+}
+
+func TestCRValidatorDockerRegistry(t *testing.T) {
+	v := newCRValidator(t)
+
+	cr := &v1alpha1.DockerRegistry{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       dockerregistry.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec:       v1alpha1.DockerRegistrySpec{Server: "registry.example.com"},
+	}
+	res := v.Handle(context.TODO(), newCRRequest(t, dockerregistry.Kind, cr))
+	require.True(t, res.Allowed, "%v", res.Result)
+
+	cr.Spec.Server = ""
+	res = v.Handle(context.TODO(), newCRRequest(t, dockerregistry.Kind, cr))
+	require.False(t, res.Allowed)
+	require.Contains(t, string(res.Result.Reason), "invalid spec of DockerRegistry: registry server must be set")
+}
+
+func TestCRValidatorPGPKeyPair(t *testing.T) {
+	v := newCRValidator(t)
+
+	cr := &v1alpha1.PGPKeyPair{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       pgpkeypair.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec:       v1alpha1.PGPKeyPairSpec{UserID: "Backup Job <backup@example.com>", Expiry: "8760h"},
+	}
+	res := v.Handle(context.TODO(), newCRRequest(t, pgpkeypair.Kind, cr))
+	require.True(t, res.Allowed, "%v", res.Result)
+
+	cr.Spec.Expiry = "one year"
+	res = v.Handle(context.TODO(), newCRRequest(t, pgpkeypair.Kind, cr))
+	require.False(t, res.Allowed)
+	require.Contains(t, string(res.Result.Reason), "invalid spec of PGPKeyPair")
+}
+
+func TestCRValidatorRejectsUnsupportedKinds(t *testing.T) {
+	v := newCRValidator(t)
+
+	res := v.Handle(context.TODO(), newCRRequest(t, "Unknown", newStringSecret()))
+	require.False(t, res.Allowed)
+	require.Contains(t, res.Result.Message, "unsupported kind Unknown")
+}
@@ -0,0 +1,95 @@
+package secretmutator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+// Path is the path the webhook is served at
+const Path = "/mutate-v1-secret"
+
+var log = logf.Log.WithName("webhook_secret_mutator")
+
+// Add creates a new SecretMutator and registers it with the webhook server of the Manager
+func Add(mgr manager.Manager) error {
+	mgr.GetWebhookServer().Register(Path, &webhook.Admission{Handler: NewSecretMutator(mgr.GetClient())})
+	return nil
+}
+
+// NewSecretMutator returns a new SecretMutator reading passphrases of key pairs using c
+func NewSecretMutator(c client.Client) *SecretMutator {
+	return &SecretMutator{client: c}
+}
+
+// SecretMutator generates the values of annotated Secrets when they are created, so they are never observed
+// without their values. If the values cannot be generated, the Secret is admitted unchanged and the Secret
+// controller generates them instead.
+type SecretMutator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// blank assignments to verify that SecretMutator implements admission.Handler and admission.DecoderInjector
+var _ admission.Handler = &SecretMutator{}
+var _ admission.DecoderInjector = &SecretMutator{}
+
+// InjectDecoder sets the decoder used to decode the Secrets of admission requests
+func (m *SecretMutator) InjectDecoder(d *admission.Decoder) error {
+	m.decoder = d
+	return nil
+}
+
+// Handle generates the values of the Secret in req, if it is being created and annotated for generation
+func (m *SecretMutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create {
+		return admission.Allowed("values are only generated on creation")
+	}
+
+	instance := &corev1.Secret{}
+	if err := m.decoder.Decode(req, instance); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if instance.Name == "" {
+		// values derived from the name, e.g. the common name of certificates, are generated by the controller
+		return admission.Allowed("values of secrets with generated names are generated by the controller")
+	}
+
+	reqLogger := log.WithValues("Request.Namespace", req.Namespace, "Request.Name", instance.Name)
+
+	desired := instance.DeepCopy()
+	if desired.Namespace == "" {
+		// the namespace is required to read passphrases from other secrets
+		desired.Namespace = req.Namespace
+	}
+
+	generated, err := secret.GenerateData(reqLogger, m.client, desired)
+	if err != nil {
+		reqLogger.Error(err, "could not generate secret, leaving generation to the controller")
+		return admission.Allowed("values are generated by the controller")
+	}
+	if !generated {
+		return admission.Allowed("secret is not annotated for generation")
+	}
+	desired.Namespace = instance.Namespace
+
+	reqLogger.Info("generated values of new secret")
+
+	marshaled, err := json.Marshal(desired)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
@@ -0,0 +1,178 @@
+package secretmutator_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/webhook/secretmutator"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("secret-length", 40)
+	viper.Set("secret-encoding", "base64")
+	viper.Set("ssh-key-length", 2048)
+
+	os.Exit(m.Run())
+}
+
+// newSecretMutator returns a SecretMutator with an injected decoder, like the webhook server does. Passphrases
+// are read from the given objects.
+func newSecretMutator(t *testing.T, objects ...runtime.Object) *secretmutator.SecretMutator {
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	require.NoError(t, err)
+
+	m := secretmutator.NewSecretMutator(fake.NewFakeClientWithScheme(scheme.Scheme, objects...))
+	require.NoError(t, m.InjectDecoder(decoder))
+
+	return m
+}
+
+// newSecretRequest returns a request creating in
+func newSecretRequest(t *testing.T, in *corev1.Secret) admission.Request {
+	in.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+	raw, err := json.Marshal(in)
+	require.NoError(t, err)
+
+	return admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Secret"},
+			Namespace: "default",
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+// patchedPaths returns the paths of the JSON patch operations of res
+func patchedPaths(res admission.Response) []string {
+	paths := make([]string, 0, len(res.Patches))
+	for _, patch := range res.Patches {
+		paths = append(paths, patch.Path)
+	}
+	return paths
+}
+
+func TestSecretMutatorGeneratesAnnotatedSecrets(t *testing.T) {
+	m := newSecretMutator(t)
+
+	res := m.Handle(context.TODO(), newSecretRequest(t, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			Annotations: map[string]string{
+				secret.AnnotationSecretAutoGenerate: "password",
+			},
+		},
+	}))
+	require.True(t, res.Allowed)
+	require.Contains(t, patchedPaths(res), "/data")
+	require.Contains(t, patchedPaths(res), "/metadata/annotations/secret-generator.v1.mittwald.de~1type")
+	require.Contains(t, patchedPaths(res), "/metadata/annotations/secret-generator.v1.mittwald.de~1autogenerate-generated-at")
+
+	for _, patch := range res.Patches {
+		if patch.Path != "/data" {
+			continue
+		}
+		data := patch.Value.(map[string]interface{})
+		password, err := base64.StdEncoding.DecodeString(data["password"].(string))
+		require.NoError(t, err)
+		require.Len(t, password, 40)
+	}
+}
+
+func TestSecretMutatorIgnoresSecretsWithoutAnnotations(t *testing.T) {
+	m := newSecretMutator(t)
+
+	res := m.Handle(context.TODO(), newSecretRequest(t, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Annotations: map[string]string{"unrelated": "value"},
+		},
+		Data: map[string][]byte{"password": []byte("test")},
+	}))
+	require.True(t, res.Allowed)
+	require.Empty(t, res.Patches)
+}
+
+func TestSecretMutatorIgnoresGeneratedNames(t *testing.T) {
+	m := newSecretMutator(t)
+
+	res := m.Handle(context.TODO(), newSecretRequest(t, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Annotations: map[string]string{
+				secret.AnnotationSecretAutoGenerate: "password",
+			},
+		},
+	}))
+	require.True(t, res.Allowed)
+	require.Empty(t, res.Patches)
+}
+
+func TestSecretMutatorAllowsSecretsFailingGeneration(t *testing.T) {
+	m := newSecretMutator(t)
+
+	// the passphrase secret does not exist, so the controller has to generate the key pair later
+	res := m.Handle(context.TODO(), newSecretRequest(t, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			Annotations: map[string]string{
+				secret.AnnotationSecretType:       string(secret.TypeSSHKeypair),
+				secret.AnnotationPassphraseSecret: "missing",
+			},
+		},
+	}))
+	require.True(t, res.Allowed)
+	require.Empty(t, res.Patches)
+}
+
+func TestSecretMutatorReadsPassphrasesFromSecrets(t *testing.T) {
+	m := newSecretMutator(t, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "passphrase", Namespace: "default"},
+		Data:       map[string][]byte{secret.SecretFieldPassphrase: []byte("correct horse battery staple")},
+	})
+
+	res := m.Handle(context.TODO(), newSecretRequest(t, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			Annotations: map[string]string{
+				secret.AnnotationSecretType:       string(secret.TypeSSHKeypair),
+				secret.AnnotationKeyAlgorithm:     string(secret.KeyAlgorithmEd25519),
+				secret.AnnotationPassphraseSecret: "passphrase",
+			},
+		},
+	}))
+	require.True(t, res.Allowed)
+	require.Contains(t, patchedPaths(res), "/data")
+}
+
+func TestSecretMutatorIgnoresUpdates(t *testing.T) {
+	m := newSecretMutator(t)
+
+	req := newSecretRequest(t, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			Annotations: map[string]string{
+				secret.AnnotationSecretAutoGenerate: "password",
+			},
+		},
+	})
+	req.Operation = admissionv1beta1.Update
+
+	res := m.Handle(context.TODO(), req)
+	require.True(t, res.Allowed)
+	require.Empty(t, res.Patches)
+}
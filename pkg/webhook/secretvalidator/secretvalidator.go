@@ -0,0 +1,58 @@
+package secretvalidator
+
+import (
+	"context"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+)
+
+// Path is the path the webhook is served at
+const Path = "/validate-v1-secret"
+
+// Add creates a new SecretValidator and registers it with the webhook server of the Manager
+func Add(mgr manager.Manager) error {
+	mgr.GetWebhookServer().Register(Path, &webhook.Admission{Handler: &SecretValidator{}})
+	return nil
+}
+
+// SecretValidator rejects Secrets whose annotations configuring the generation of values are invalid,
+// so that they are not only detected by the Secret controller
+type SecretValidator struct {
+	decoder *admission.Decoder
+}
+
+// blank assignments to verify that SecretValidator implements admission.Handler and admission.DecoderInjector
+var _ admission.Handler = &SecretValidator{}
+var _ admission.DecoderInjector = &SecretValidator{}
+
+// InjectDecoder sets the decoder used to decode the Secrets of admission requests
+func (v *SecretValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle denies the Secret in req, if it is annotated for generation and any of its annotations is invalid
+func (v *SecretValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	instance := &corev1.Secret{}
+	if err := v.decoder.Decode(req, instance); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	_, hasType := instance.Annotations[secret.AnnotationSecretType]
+	_, hasAutoGenerate := instance.Annotations[secret.AnnotationSecretAutoGenerate]
+	if !hasType && !hasAutoGenerate {
+		return admission.Allowed("secret is not annotated for generation")
+	}
+
+	if err := secret.ValidateAnnotations(instance.Annotations); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	return admission.Allowed("")
+}
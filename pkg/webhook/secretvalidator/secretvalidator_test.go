@@ -0,0 +1,129 @@
+package secretvalidator_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/mittwald/kubernetes-secret-generator/pkg/controller/secret"
+	"github.com/mittwald/kubernetes-secret-generator/pkg/webhook/secretvalidator"
+)
+
+// newSecretValidator returns a SecretValidator with an injected decoder, like the webhook server does
+func newSecretValidator(t *testing.T) *secretvalidator.SecretValidator {
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	require.NoError(t, err)
+
+	v := &secretvalidator.SecretValidator{}
+	require.NoError(t, v.InjectDecoder(decoder))
+
+	return v
+}
+
+// newSecretRequest returns a request creating a Secret with the given annotations
+func newSecretRequest(t *testing.T, annotations map[string]string) admission.Request {
+	raw, err := json.Marshal(&corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+	})
+	require.NoError(t, err)
+
+	return admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Secret"},
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+func TestSecretValidatorAllowsValidSecrets(t *testing.T) {
+	v := newSecretValidator(t)
+
+	allowed := []map[string]string{
+		nil,
+		{"unrelated": "value"},
+		{
+			secret.AnnotationSecretAutoGenerate: "password",
+			secret.AnnotationSecretLength:       "32",
+			secret.AnnotationSecretEncoding:     "base32",
+		},
+		{
+			secret.AnnotationSecretType:   string(secret.TypeSSHKeypair),
+			secret.AnnotationKeyAlgorithm: string(secret.KeyAlgorithmEd25519),
+		},
+	}
+
+	for _, annotations := range allowed {
+		res := v.Handle(context.TODO(), newSecretRequest(t, annotations))
+		require.True(t, res.Allowed, "%v: %v", annotations, res.Result)
+	}
+}
+
+func TestSecretValidatorDeniesInvalidAnnotations(t *testing.T) {
+	v := newSecretValidator(t)
+
+	denied := map[string]struct {
+		annotations map[string]string
+		message     string
+	}{
+		"invalid length": {
+			annotations: map[string]string{
+				secret.AnnotationSecretAutoGenerate: "password",
+				secret.AnnotationSecretLength:       "abc",
+			},
+			message: "invalid value for annotation " + secret.AnnotationSecretLength,
+		},
+		"unknown encoding": {
+			annotations: map[string]string{
+				secret.AnnotationSecretAutoGenerate: "password",
+				secret.AnnotationSecretEncoding:     "base58",
+			},
+			message: "invalid value for annotation " + secret.AnnotationSecretEncoding + ": base58 is not a valid encoding",
+		},
+		"duplicate field": {
+			annotations: map[string]string{
+				secret.AnnotationSecretAutoGenerate: "password,password",
+			},
+			message: "invalid value for annotation " + secret.AnnotationSecretAutoGenerate,
+		},
+		"unknown type": {
+			annotations: map[string]string{
+				secret.AnnotationSecretAutoGenerate: "password",
+				secret.AnnotationSecretType:         "password",
+			},
+			message: "invalid value for annotation " + secret.AnnotationSecretType + ": password is not a valid secret type",
+		},
+	}
+
+	for name, test := range denied {
+		res := v.Handle(context.TODO(), newSecretRequest(t, test.annotations))
+		require.False(t, res.Allowed, name)
+		require.Contains(t, string(res.Result.Reason), test.message, name)
+	}
+}
+
+func TestSecretValidatorRejectsUndecodableObjects(t *testing.T) {
+	v := newSecretValidator(t)
+
+	req := newSecretRequest(t, nil)
+	req.Object.Raw = []byte("{")
+
+	res := v.Handle(context.TODO(), req)
+	require.False(t, res.Allowed)
+}